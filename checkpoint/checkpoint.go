@@ -0,0 +1,70 @@
+package checkpoint
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Ysoding/pokemon-wiki-spider/spider"
+)
+
+// Snapshot 某一时刻的抓取进度.
+type Snapshot struct {
+	Time     time.Time
+	Pending  []spider.Record // 已入队但还没处理完的请求, 包括正在抓取和等待重试的
+	Visited  []string        // Request.Unique()
+	Failures []spider.Record
+	Finished []string // 已经完成的任务
+}
+
+type Store interface {
+	Save(s *Snapshot) error
+	// Load 没有断点时返回 nil, nil
+	Load() (*Snapshot, error)
+}
+
+type FileStore struct {
+	path string
+}
+
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// Save 先写临时文件再改名, 进程中途崩溃也不会留下写了一半的断点.
+func (s *FileStore) Save(snapshot *Snapshot) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, s.path)
+}
+
+func (s *FileStore) Load() (*Snapshot, error) {
+	b, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &Snapshot{}
+	if err := json.Unmarshal(b, snapshot); err != nil {
+		return nil, err
+	}
+
+	return snapshot, nil
+}
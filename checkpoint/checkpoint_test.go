@@ -0,0 +1,47 @@
+package checkpoint
+
+import (
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Ysoding/pokemon-wiki-spider/spider"
+)
+
+func TestFileStoreRoundTrip(t *testing.T) {
+	store := NewFileStore(filepath.Join(t.TempDir(), "logs", "checkpoint.json"))
+
+	// 没有断点时返回 nil, nil
+	if s, err := store.Load(); s != nil || err != nil {
+		t.Fatalf("Load = %v, %v", s, err)
+	}
+
+	want := &Snapshot{
+		Time: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Pending: []spider.Record{{
+			Task:     "move_detail",
+			URL:      "https://wiki.52poke.com/wiki/十万伏特",
+			Method:   "GET",
+			Header:   http.Header{"Accept-Language": {"zh-CN"}},
+			RuleName: "detail",
+			Depth:    2,
+			Attempt:  1,
+		}},
+		Visited:  []string{"a", "b"},
+		Failures: []spider.Record{{Task: "move_detail", URL: "https://a/1", Body: []byte("x"), Attempt: 3}},
+		Finished: []string{"move_list"},
+	}
+	if err := store.Save(want); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Load = %+v, want %+v", got, want)
+	}
+}
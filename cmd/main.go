@@ -2,18 +2,27 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/Ysoding/pokemon-wiki-spider/checkpoint"
 	"github.com/Ysoding/pokemon-wiki-spider/collect"
 	"github.com/Ysoding/pokemon-wiki-spider/conf"
+	"github.com/Ysoding/pokemon-wiki-spider/deadletter"
+	"github.com/Ysoding/pokemon-wiki-spider/dedup"
 	"github.com/Ysoding/pokemon-wiki-spider/engine"
 	"github.com/Ysoding/pokemon-wiki-spider/global"
-	"github.com/Ysoding/pokemon-wiki-spider/parse/pokemon"
+	_ "github.com/Ysoding/pokemon-wiki-spider/parse/pokemon"
+	"github.com/Ysoding/pokemon-wiki-spider/parse/table"
 	"github.com/Ysoding/pokemon-wiki-spider/spider"
+	csvstorage "github.com/Ysoding/pokemon-wiki-spider/storage/csv"
 	mongostorage "github.com/Ysoding/pokemon-wiki-spider/storage/mongo"
 	"github.com/joho/godotenv"
 	"go.uber.org/zap"
@@ -25,12 +34,86 @@ func main() {
 
 	ctx := context.Background()
 
-	if err := run(ctx); err != nil {
+	if err := run(ctx, os.Args[1:]); err != nil {
 		os.Exit(1)
 	}
 }
 
-func run(context.Context) error {
+// 用法:
+//
+//	main [crawl] --task pokemon_list,move_list [-resume]   抓取指定任务, -resume 从上次的断点继续
+//	main [crawl] --all [-resume]                           抓取所有任务
+//	main list-tasks                                        列出所有任务
+//	main replay [-file dead_letter]                        重新抓取死信文件里的请求
+//
+// -max-requests, -max-items, -max-duration 限制每个任务的抓取量, 例如只抓前 20 个宝可梦详情.
+// -csv data 把数据写成 CSV 文件, 表头由规则的 ItemFields 生成.
+// -control 127.0.0.1:6060 开启本地控制接口: GET /status, POST /pause, POST /resume.
+// -dedup file 或 -dedup bloom 会把访问记录保存到 -dedup-file, 下次运行跳过已经抓过的页面.
+// -config tasks.json 从配置文件加载表格任务, 格式见 configs/tasks.example.json.
+func run(ctx context.Context, args []string) error {
+	cmd := "crawl"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		cmd, args = args[0], args[1:]
+	}
+
+	fs := flag.NewFlagSet(cmd, flag.ContinueOnError)
+	taskNames := fs.String("task", "", "comma separated task names, see list-tasks")
+	all := fs.Bool("all", false, "crawl all registered tasks")
+	deadLetterFile := fs.String("file", global.DefaultDeadLetterFile, "dead letter file")
+	checkpointFile := fs.String("checkpoint", global.DefaultCheckpointFile, "checkpoint file")
+	resume := fs.Bool("resume", false, "resume from the last checkpoint")
+	maxRequests := fs.Int64("max-requests", 0, "max requests per task, 0 means unlimited")
+	maxItems := fs.Int64("max-items", 0, "max items per task, 0 means unlimited")
+	maxDuration := fs.Duration("max-duration", 0, "max duration per task, 0 means unlimited")
+	csvDir := fs.String("csv", "", "write items as CSV files into this directory instead of MongoDB")
+	controlAddr := fs.String("control", "", "local control address such as 127.0.0.1:6060, empty to disable")
+	dedupKind := fs.String("dedup", "memory", "visited store: memory, file or bloom")
+	dedupFile := fs.String("dedup-file", global.DefaultDedupFile, "visited store file for -dedup file/bloom")
+	configFile := fs.String("config", "", "JSON file of table tasks, empty to disable")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *configFile != "" {
+		if err := loadConfig(*configFile); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return err
+		}
+	}
+
+	var seeds []*spider.Task
+	replay := false
+	switch cmd {
+	case "crawl":
+		var err error
+		if seeds, err = selectTasks(*taskNames, *all); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return err
+		}
+		// 命令行的预算覆盖任务自己的设置
+		for _, task := range seeds {
+			if *maxRequests > 0 {
+				task.MaxRequests = *maxRequests
+			}
+			if *maxItems > 0 {
+				task.MaxItems = *maxItems
+			}
+			if *maxDuration > 0 {
+				task.MaxDuration = *maxDuration
+			}
+		}
+	case "replay":
+		replay = true
+	case "list-tasks":
+		listTasks()
+		return nil
+	default:
+		err := fmt.Errorf("unknown command %q", cmd)
+		fmt.Fprintln(os.Stderr, err)
+		return err
+	}
+
 	shutdown := make(chan os.Signal, 1)
 	signal.Notify(shutdown, syscall.SIGINT, syscall.SIGTERM)
 
@@ -49,8 +132,33 @@ func run(context.Context) error {
 		return err
 	}
 
+	// 收尾超时时 worker 可能还在写入, 不关闭共享的 storage, 死信和访问记录, 交给进程退出.
+	// 访问记录在最后一次保存断点时已经落盘.
+	timedOut := false
+	defer func() {
+		if timedOut {
+			logger.Warn("shutdown timeout, leave storage, dead letter and dedup store open")
+		}
+	}()
+	closeSink := func(name string, close func() error) {
+		if timedOut {
+			return
+		}
+		if err := close(); err != nil {
+			logger.Error("close fail", zap.String("sink", name), zap.Error(err))
+		}
+	}
+
 	var storage spider.Storage
-	if global.EnableMongoDB {
+	if *csvDir != "" {
+		csvStorage, err := csvstorage.New(*csvDir, csvstorage.WithLogger(logger))
+		if err != nil {
+			logger.Error("open csv storage fail", zap.Error(err))
+			return err
+		}
+		defer closeSink("csv storage", csvStorage.Close)
+		storage = csvStorage
+	} else if global.EnableMongoDB {
 		mongoURI := os.Getenv("MONGO_URL")
 		storage, err = mongostorage.New(mongostorage.WithConnURI(mongoURI),
 			mongostorage.WithLogger(logger),
@@ -61,10 +169,39 @@ func run(context.Context) error {
 		}
 	}
 
-	seeds := pokemon.Tasks
+	var requests []*spider.Request
+	if replay {
+		requests, err = loadDeadLetter(*deadLetterFile)
+		if err != nil {
+			logger.Error("load dead letter fail", zap.String("file", *deadLetterFile), zap.Error(err))
+			return err
+		}
+		logger.Info("replay dead letter", zap.String("file", *deadLetterFile), zap.Int("requests", len(requests)))
+	}
+
+	deadLetter, err := deadletter.NewFileStore(*deadLetterFile)
+	if err != nil {
+		logger.Error("open dead letter fail", zap.Error(err))
+		return err
+	}
+	defer closeSink("dead letter", deadLetter.Close)
+
+	visited, err := openDedup(*dedupKind, *dedupFile)
+	if err != nil {
+		logger.Error("open dedup store fail", zap.String("dedup", *dedupKind), zap.Error(err))
+		return err
+	}
+	defer closeSink("dedup store", visited.Close)
+
 	e := engine.NewEngine(engine.WithLogger(logger),
-		engine.WithScheduler(engine.NewSchedule()),
+		engine.WithScheduler(engine.NewFairSchedule(global.DefaultAgingInterval)),
 		engine.WithSeeds(seeds),
+		engine.WithRequests(requests),
+		engine.WithDeadLetter(deadLetter),
+		engine.WithDedup(visited),
+		engine.WithHostLimit(global.WikiHost, global.WikiMaxPerHost),
+		engine.WithCheckpoint(checkpoint.NewFileStore(*checkpointFile), global.DefaultCheckpointInterval),
+		engine.WithResume(*resume),
 		engine.WithStorage(storage),
 		engine.WithFetcher(collect.BrowserFetch{
 			Timeout: 5 * time.Second,
@@ -72,22 +209,133 @@ func run(context.Context) error {
 		}),
 	)
 
+	if *controlAddr != "" {
+		control := &http.Server{Addr: *controlAddr, Handler: e.ControlHandler()}
+		go func() {
+			logger.Info("control server startup", zap.String("addr", *controlAddr))
+			if err := control.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				logger.Error("control server", zap.Error(err))
+			}
+		}()
+		defer control.Close()
+	}
+
 	go func() {
 		logger.Sugar().Infow("engine startup")
-		serverErrorSignal <- e.Run()
+		summary, err := e.Run(ctx)
+		logger.Sugar().Infow("engine stopped", "summary", summary)
+		serverErrorSignal <- err
 	}()
 
 	// shutdown
 	select {
 	case err := <-serverErrorSignal:
 		if err != nil {
+			timedOut = errors.Is(err, engine.ErrShutdownTimeout)
 			return fmt.Errorf("server error: %w", err)
 		}
 
 	case sig := <-shutdown:
 		logger.Sugar().Infow("shutdown", "status", "shutdown started", "signal", sig)
 		defer logger.Sugar().Infow("shutdown", "status", "shutdown complete", "signal", sig)
-		e.Shutdown()
+		if err := e.Shutdown(); err != nil {
+			timedOut = errors.Is(err, engine.ErrShutdownTimeout)
+			logger.Error("shutdown", zap.Error(err))
+			return err
+		}
+	}
+	return nil
+}
+
+func openDedup(kind, file string) (dedup.Store, error) {
+	switch kind {
+	case "memory":
+		return dedup.NewMemory(), nil
+	case "file":
+		return dedup.NewFile(file)
+	case "bloom":
+		return dedup.OpenBloom(file, global.DefaultBloomCapacity, global.DefaultBloomFalsePositive)
+	default:
+		return nil, fmt.Errorf("unknown dedup store %q", kind)
+	}
+}
+
+// loadDeadLetter 读取死信文件并把它改名备份, 重放中再次失败的请求会写入新文件.
+// 有未知任务时直接返回错误, 文件保持原样.
+func loadDeadLetter(file string) ([]*spider.Request, error) {
+	entries, err := deadletter.Load(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var requests []*spider.Request
+	for _, e := range entries {
+		task := spider.LookupTask(e.Task)
+		if task == nil {
+			return nil, fmt.Errorf("unknown task %q for %s", e.Task, e.URL)
+		}
+
+		req := e.Request(task)
+		req.Attempt = 0
+		requests = append(requests, req)
+	}
+
+	if err := os.Rename(file, fmt.Sprintf("%s.%d", file, time.Now().Unix())); err != nil {
+		return nil, err
+	}
+
+	return requests, nil
+}
+
+// loadConfig 登记配置文件里的任务, 不能和已有的任务重名.
+func loadConfig(file string) error {
+	tasks, err := table.Load(file)
+	if err != nil {
+		return err
 	}
+
+	for _, task := range tasks {
+		if spider.LookupTask(task.Name) != nil {
+			return fmt.Errorf("%s: task %q already registered", file, task.Name)
+		}
+	}
+	spider.Register(tasks...)
+
 	return nil
 }
+
+func selectTasks(names string, all bool) ([]*spider.Task, error) {
+	if all {
+		return spider.RegisteredTasks(), nil
+	}
+
+	if names == "" {
+		return nil, fmt.Errorf("no task selected, use --task or --all")
+	}
+
+	var tasks []*spider.Task
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		task := spider.LookupTask(name)
+		if task == nil {
+			return nil, fmt.Errorf("unknown task %q, see list-tasks", name)
+		}
+		tasks = append(tasks, task)
+	}
+
+	return tasks, nil
+}
+
+func listTasks() {
+	for _, task := range spider.RegisteredTasks() {
+		if len(task.DependsOn) > 0 {
+			fmt.Printf("%s\t(depends on %s)\n", task.Name, strings.Join(task.DependsOn, ","))
+		} else {
+			fmt.Println(task.Name)
+		}
+	}
+}
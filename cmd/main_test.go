@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/Ysoding/pokemon-wiki-spider/deadletter"
+	"github.com/Ysoding/pokemon-wiki-spider/engine"
+	"github.com/Ysoding/pokemon-wiki-spider/spider"
+)
+
+// replayFetch 记录抓取过的 URL, 总是返回 200.
+type replayFetch struct {
+	mu   sync.Mutex
+	urls []string
+}
+
+func (f *replayFetch) Get(ctx context.Context, req *spider.Request) (*spider.Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.urls = append(f.urls, req.URL)
+	return &spider.Response{StatusCode: http.StatusOK}, nil
+}
+
+var (
+	replayRoots int
+	replayTask  = &spider.Task{
+		Options: spider.Options{Name: "replay_test", MaxDepth: 5},
+		Rule: spider.RuleTree{
+			Root: func() ([]*spider.Request, error) {
+				replayRoots++
+				return []*spider.Request{{URL: "https://a/list", RuleName: "parse"}}, nil
+			},
+			Trunk: map[string]*spider.Rule{
+				"parse": {ParseFunc: func(ctx *spider.Context) (spider.ParseResult, error) {
+					return spider.ParseResult{}, nil
+				}},
+			},
+		},
+	}
+)
+
+func init() {
+	spider.Register(replayTask)
+}
+
+func writeDeadLetter(t *testing.T, entries ...*deadletter.Entry) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "dead_letter.jsonl")
+	store, err := deadletter.NewFileStore(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if err := store.Put(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestLoadDeadLetterUnknownTask(t *testing.T) {
+	file := writeDeadLetter(t,
+		deadletter.NewEntry(&spider.Request{URL: "https://a/1", Task: replayTask, Attempt: 3}, nil),
+		&deadletter.Entry{Record: spider.Record{Task: "no_such_task", URL: "https://a/2"}},
+	)
+
+	if _, err := loadDeadLetter(file); err == nil {
+		t.Fatal("want error for unknown task")
+	}
+	// 失败时死信文件不能被移走
+	if _, err := os.Stat(file); err != nil {
+		t.Fatalf("dead letter moved: %v", err)
+	}
+}
+
+func TestReplayDeadLetter(t *testing.T) {
+	failed := &spider.Request{
+		URL:      "https://a/detail",
+		RuleName: "parse",
+		Task:     replayTask,
+		Depth:    2,
+		Attempt:  3,
+	}
+	file := writeDeadLetter(t, deadletter.NewEntry(failed, nil))
+
+	requests, err := loadDeadLetter(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(file); !os.IsNotExist(err) {
+		t.Fatalf("dead letter not moved away: %v", err)
+	}
+	if len(requests) != 1 {
+		t.Fatalf("got %d requests", len(requests))
+	}
+	req := requests[0]
+	if req.Attempt != 0 || req.Task != replayTask || req.Depth != 2 || req.URL != failed.URL {
+		t.Fatalf("replayed request = %+v", req)
+	}
+
+	fetch := &replayFetch{}
+	e := engine.NewEngine(
+		engine.WithScheduler(engine.NewSchedule()),
+		engine.WithFetcher(fetch),
+		engine.WithRequests(requests),
+	)
+	if _, err := e.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if replayRoots != 0 {
+		t.Fatalf("Root called %d times during replay", replayRoots)
+	}
+	if len(fetch.urls) != 1 || fetch.urls[0] != failed.URL {
+		t.Fatalf("fetched %v, want only the dead letter request", fetch.urls)
+	}
+}
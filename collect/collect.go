@@ -2,6 +2,7 @@ package collect
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"io"
 	"net/http"
@@ -18,23 +19,20 @@ import (
 
 type BaseFetch struct{}
 
-func (BaseFetch) Get(req *spider.Request) ([]byte, error) {
-	resp, err := http.Get(req.URL)
+func (BaseFetch) Get(ctx context.Context, req *spider.Request) (*spider.Response, error) {
+	r, err := req.HTTPRequest(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("error status code:%d", resp.StatusCode)
+	resp, err := http.DefaultClient.Do(r)
+	if err != nil {
+		return nil, err
 	}
 
-	bodyReader := bufio.NewReader(resp.Body)
-	e := DeterminEncoding(bodyReader)
-	utf8Reader := transform.NewReader(bodyReader, e.NewDecoder())
+	defer resp.Body.Close()
 
-	return io.ReadAll(utf8Reader)
+	return readResponse(resp)
 }
 
 type BrowserFetch struct {
@@ -43,7 +41,7 @@ type BrowserFetch struct {
 	Proxy   ProxyFunc
 }
 
-func (b BrowserFetch) Get(request *spider.Request) ([]byte, error) {
+func (b BrowserFetch) Get(ctx context.Context, request *spider.Request) (*spider.Response, error) {
 	client := &http.Client{}
 
 	if b.Timeout != 0 {
@@ -56,34 +54,55 @@ func (b BrowserFetch) Get(request *spider.Request) ([]byte, error) {
 		client.Transport = transport
 	}
 
-	req, err := http.NewRequest(request.Method, request.URL, nil)
+	req, err := request.HTTPRequest(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("get url failed:%w", err)
 	}
 
-	if len(request.Task.Cookie) > 0 {
+	// 请求自己设置的 header 优先
+	if len(request.Task.Cookie) > 0 && req.Header.Get("Cookie") == "" {
 		req.Header.Set("Cookie", request.Task.Cookie)
 	}
 
-	req.Header.Set("User-Agent", global.GenerateRandomUA())
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", global.GenerateRandomUA())
+	}
 
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, err
 	}
 
+	defer resp.Body.Close()
+
+	return readResponse(resp)
+}
+
+func readResponse(resp *http.Response) (*spider.Response, error) {
 	bodyReader := bufio.NewReader(resp.Body)
 	e := DeterminEncoding(bodyReader)
 	utf8Reader := transform.NewReader(bodyReader, e.NewDecoder())
 
-	return io.ReadAll(utf8Reader)
+	body, err := io.ReadAll(utf8Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return &spider.Response{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       body,
+	}, nil
 }
 
 func DeterminEncoding(r *bufio.Reader) encoding.Encoding {
 	bytes, err := r.Peek(1024)
 
-	if err != nil {
-		zap.L().Error("fetch failed", zap.Error(err))
+	// body 不足 1024 字节时用已读到的部分判断
+	if err != nil && len(bytes) == 0 {
+		if err != io.EOF {
+			zap.L().Error("fetch failed", zap.Error(err))
+		}
 
 		return unicode.UTF8
 	}
@@ -0,0 +1,93 @@
+package deadletter
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/Ysoding/pokemon-wiki-spider/spider"
+)
+
+// Entry 重试耗尽的请求.
+type Entry struct {
+	spider.Record
+	Error string
+	Time  time.Time
+}
+
+func NewEntry(req *spider.Request, err error) *Entry {
+	e := &Entry{
+		Record: req.Record(),
+		Time:   time.Now(),
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	return e
+}
+
+type Store interface {
+	Put(e *Entry) error
+	Close() error
+}
+
+// FileStore 以 JSONL 格式追加写入文件, 一行一个 Entry.
+type FileStore struct {
+	mu  sync.Mutex
+	f   *os.File
+	enc *json.Encoder
+}
+
+func NewFileStore(path string) (*FileStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileStore{f: f, enc: json.NewEncoder(f)}, nil
+}
+
+func (s *FileStore) Put(e *Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.enc.Encode(e)
+}
+
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.f.Close()
+}
+
+func Load(path string) ([]*Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []*Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		e := &Entry{}
+		if err := json.Unmarshal(line, e); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, scanner.Err()
+}
@@ -0,0 +1,103 @@
+package deadletter
+
+import (
+	"errors"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Ysoding/pokemon-wiki-spider/spider"
+)
+
+func TestFileStoreRoundTrip(t *testing.T) {
+	task := &spider.Task{Options: spider.Options{Name: "move_detail"}}
+	reqs := []*spider.Request{
+		{
+			URL:      "https://wiki.52poke.com/wiki/十万伏特",
+			Method:   "GET",
+			RuleName: "detail",
+			Depth:    2,
+			Priority: 10,
+			Attempt:  3,
+			Task:     task,
+			Header:   http.Header{"Accept-Language": {"zh-CN"}},
+		},
+		{
+			URL:         "https://example.com/api",
+			Method:      "POST",
+			RuleName:    "api",
+			Depth:       1,
+			Task:        task,
+			Body:        []byte(`{"page":1}`),
+			ContentType: "application/json",
+		},
+	}
+
+	// 目录不存在时自动创建
+	file := filepath.Join(t.TempDir(), "logs", "dead_letter.jsonl")
+	store, err := NewFileStore(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put(NewEntry(reqs[0], errors.New("error status code:503"))); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put(NewEntry(reqs[1], nil)); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := Load(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != len(reqs) {
+		t.Fatalf("got %d entries, want %d", len(entries), len(reqs))
+	}
+	for i, e := range entries {
+		if want := reqs[i].Record(); !reflect.DeepEqual(e.Record, want) {
+			t.Errorf("entry %d = %+v, want %+v", i, e.Record, want)
+		}
+		if e.Time.IsZero() {
+			t.Errorf("entry %d has no time", i)
+		}
+	}
+	if entries[0].Error != "error status code:503" || entries[1].Error != "" {
+		t.Fatalf("errors = %q, %q", entries[0].Error, entries[1].Error)
+	}
+
+	req := entries[1].Request(task)
+	if req.Task != task || req.URL != reqs[1].URL || string(req.Body) != `{"page":1}` || req.Method != "POST" {
+		t.Fatalf("request = %+v", req)
+	}
+}
+
+func TestFileStoreAppends(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "dead_letter.jsonl")
+	task := &spider.Task{Options: spider.Options{Name: "list"}}
+
+	// 重新打开时追加, 不覆盖之前的死信
+	for _, u := range []string{"https://a/1", "https://a/2"} {
+		store, err := NewFileStore(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := store.Put(NewEntry(&spider.Request{URL: u, Task: task}, nil)); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err := Load(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].URL != "https://a/1" || entries[1].URL != "https://a/2" {
+		t.Fatalf("entries = %+v", entries)
+	}
+}
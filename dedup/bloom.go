@@ -0,0 +1,164 @@
+package dedup
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Bloom 布隆过滤器, 内存占用固定, 但有误判: 没访问过的页面可能被当成访问过.
+// 指定了 path 时 Sync 和 Close 会把位图写回文件.
+type Bloom struct {
+	mu   sync.Mutex
+	bits []uint64
+	m    uint64 // 位数
+	k    uint64 // 哈希函数个数
+	path string
+}
+
+// NewBloom n 为预计的 key 数量, p 为可以接受的误判率, 必须在 (0, 1) 之间.
+func NewBloom(n uint64, p float64) (*Bloom, error) {
+	if n == 0 {
+		return nil, errors.New("dedup: bloom capacity must be positive")
+	}
+	if !(p > 0 && p < 1) {
+		return nil, fmt.Errorf("dedup: bloom false positive rate %v not in (0, 1)", p)
+	}
+
+	m := uint64(math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2)))
+	if m < 64 {
+		m = 64
+	}
+	k := uint64(math.Round(float64(m) / float64(n) * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+	return &Bloom{
+		bits: make([]uint64, (m+63)/64),
+		m:    m,
+		k:    k,
+	}, nil
+}
+
+// OpenBloom 从 path 读取之前保存的过滤器, 文件不存在时按 n, p 新建.
+func OpenBloom(path string, n uint64, p float64) (*Bloom, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		b, err := NewBloom(n, p)
+		if err != nil {
+			return nil, err
+		}
+		b.path = path
+		return b, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	b := &Bloom{path: path}
+	if _, err := b.ReadFrom(f); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// locations double hashing: h1 + i*h2
+func (b *Bloom) locations(key string) []uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	h1 := h.Sum64()
+	h.Write([]byte{0})
+	h2 := h.Sum64() | 1
+
+	locs := make([]uint64, b.k)
+	for i := uint64(0); i < b.k; i++ {
+		locs[i] = (h1 + i*h2) % b.m
+	}
+	return locs
+}
+
+func (b *Bloom) Has(key string) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, loc := range b.locations(key) {
+		if b.bits[loc/64]&(1<<(loc%64)) == 0 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (b *Bloom) Add(key string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, loc := range b.locations(key) {
+		b.bits[loc/64] |= 1 << (loc % 64)
+	}
+	return nil
+}
+
+func (b *Bloom) WriteTo(w io.Writer) (int64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := binary.Write(w, binary.LittleEndian, []uint64{b.m, b.k}); err != nil {
+		return 0, err
+	}
+	if err := binary.Write(w, binary.LittleEndian, b.bits); err != nil {
+		return 16, err
+	}
+	return int64(16 + 8*len(b.bits)), nil
+}
+
+func (b *Bloom) ReadFrom(r io.Reader) (int64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	header := make([]uint64, 2)
+	if err := binary.Read(r, binary.LittleEndian, header); err != nil {
+		return 0, err
+	}
+	if header[0] == 0 || header[1] == 0 {
+		return 16, errors.New("dedup: invalid bloom filter header")
+	}
+	bits := make([]uint64, (header[0]+63)/64)
+	if err := binary.Read(r, binary.LittleEndian, bits); err != nil {
+		return 16, err
+	}
+	b.m, b.k, b.bits = header[0], header[1], bits
+	return int64(16 + 8*len(bits)), nil
+}
+
+// Sync 把位图写回文件, 保存断点时调用, 进程崩溃也不会丢掉之前的访问记录.
+func (b *Bloom) Sync() error {
+	if b.path == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(b.path), 0o755); err != nil {
+		return err
+	}
+
+	tmp := b.path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := b.WriteTo(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, b.path)
+}
+
+func (b *Bloom) Close() error {
+	return b.Sync()
+}
@@ -0,0 +1,18 @@
+package dedup
+
+// Store 记录已经访问过的请求, key 为 Request.Unique().
+type Store interface {
+	Has(key string) (bool, error)
+	Add(key string) error
+	Close() error
+}
+
+// Lister 能列出全部 key 的 Store, 保存断点时用到.
+type Lister interface {
+	Keys() []string
+}
+
+// Syncer 需要显式落盘的 Store, 保存断点时调用.
+type Syncer interface {
+	Sync() error
+}
@@ -0,0 +1,167 @@
+package dedup
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testStore(t *testing.T, s Store) {
+	t.Helper()
+	if ok, err := s.Has("a"); err != nil || ok {
+		t.Fatalf("Has(a) before Add = %v, %v", ok, err)
+	}
+	if err := s.Add("a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Add("a"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := s.Has("a"); err != nil || !ok {
+		t.Fatalf("Has(a) after Add = %v, %v", ok, err)
+	}
+	if ok, err := s.Has("b"); err != nil || ok {
+		t.Fatalf("Has(b) = %v, %v", ok, err)
+	}
+}
+
+func TestMemory(t *testing.T) {
+	m := NewMemory()
+	testStore(t, m)
+	if keys := m.Keys(); len(keys) != 1 || keys[0] != "a" {
+		t.Fatalf("Keys() = %v", keys)
+	}
+}
+
+func TestFileReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "visited")
+
+	f, err := NewFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testStore(t, f)
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err = NewFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if ok, _ := f.Has("a"); !ok {
+		t.Fatal("key lost after reopen")
+	}
+	if keys := f.Keys(); len(keys) != 1 {
+		t.Fatalf("Keys() = %v, want one key", keys)
+	}
+}
+
+func TestFileWritesOnSync(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "visited")
+
+	f, err := NewFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := f.Add("a"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Sync 之前只在内存里, 崩溃后下次运行还会抓取
+	if b, _ := os.ReadFile(path); len(b) != 0 {
+		t.Fatalf("file = %q before Sync", b)
+	}
+	if ok, _ := f.Has("a"); !ok {
+		t.Fatal("added key not found")
+	}
+
+	if err := f.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Add("a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(path); string(b) != "a\n" {
+		t.Fatalf("file = %q after Sync", b)
+	}
+}
+
+func TestNewBloomParams(t *testing.T) {
+	for _, tt := range []struct {
+		n  uint64
+		p  float64
+		ok bool
+	}{
+		{n: 1000, p: 0.01, ok: true},
+		{n: 1, p: 0.5, ok: true},
+		{n: 0, p: 0.01},
+		{n: 1000, p: 0},
+		{n: 1000, p: 1},
+		{n: 1000, p: 2},
+		{n: 1000, p: -0.1},
+	} {
+		b, err := NewBloom(tt.n, tt.p)
+		if (err == nil) != tt.ok {
+			t.Errorf("NewBloom(%d, %v) err = %v", tt.n, tt.p, err)
+			continue
+		}
+		if tt.ok {
+			testStore(t, b)
+		}
+	}
+}
+
+func TestBloomFalsePositive(t *testing.T) {
+	b, err := NewBloom(1000, 0.01)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 1000; i++ {
+		b.Add(fmt.Sprintf("in-%d", i))
+	}
+	for i := 0; i < 1000; i++ {
+		if ok, _ := b.Has(fmt.Sprintf("in-%d", i)); !ok {
+			t.Fatalf("false negative for in-%d", i)
+		}
+	}
+
+	fp := 0
+	for i := 0; i < 10000; i++ {
+		if ok, _ := b.Has(fmt.Sprintf("out-%d", i)); ok {
+			fp++
+		}
+	}
+	// 预期 1%, 留足余量
+	if fp > 300 {
+		t.Fatalf("false positives = %d/10000", fp)
+	}
+}
+
+func TestBloomSync(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "visited.bloom")
+
+	b, err := OpenBloom(path, 1000, 0.01)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Add("a")
+	// 不 Close, 模拟保存断点后崩溃
+	if err := b.Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err = OpenBloom(path, 1000, 0.01)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := b.Has("a"); !ok {
+		t.Fatal("key lost after sync")
+	}
+}
@@ -0,0 +1,100 @@
+package dedup
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// File 追加写入的 key 日志, 打开时全部读进内存, 下次运行可以跳过之前抓过的页面.
+// Add 只记在内存里, Sync 或者 Close 时才写入文件, 调用方在 Sync 之前保证对应的数据已经保存.
+type File struct {
+	mu       sync.Mutex
+	f        *os.File
+	keys     map[string]struct{}
+	unsynced []string
+}
+
+func NewFile(path string) (*File, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]struct{})
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if k := scanner.Text(); k != "" {
+			keys[k] = struct{}{}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return &File{f: f, keys: keys}, nil
+}
+
+func (s *File) Has(key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.keys[key]
+	return ok, nil
+}
+
+func (s *File) Add(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.keys[key]; ok {
+		return nil
+	}
+	s.keys[key] = struct{}{}
+	s.unsynced = append(s.unsynced, key)
+	return nil
+}
+
+func (s *File) Keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := make([]string, 0, len(s.keys))
+	for k := range s.keys {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func (s *File) Sync() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sync()
+}
+
+func (s *File) sync() error {
+	if len(s.unsynced) > 0 {
+		w := bufio.NewWriter(s.f)
+		for _, k := range s.unsynced {
+			w.WriteString(k + "\n")
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		s.unsynced = nil
+	}
+	return s.f.Sync()
+}
+
+func (s *File) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.sync(); err != nil {
+		s.f.Close()
+		return err
+	}
+	return s.f.Close()
+}
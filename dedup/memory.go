@@ -0,0 +1,40 @@
+package dedup
+
+import "sync"
+
+type Memory struct {
+	mu   sync.Mutex
+	keys map[string]struct{}
+}
+
+func NewMemory() *Memory {
+	return &Memory{keys: make(map[string]struct{})}
+}
+
+func (m *Memory) Has(key string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.keys[key]
+	return ok, nil
+}
+
+func (m *Memory) Add(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.keys[key] = struct{}{}
+	return nil
+}
+
+func (m *Memory) Keys() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	keys := make([]string, 0, len(m.keys))
+	for k := range m.keys {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func (m *Memory) Close() error {
+	return nil
+}
@@ -0,0 +1,121 @@
+package engine
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/Ysoding/pokemon-wiki-spider/spider"
+	"go.uber.org/zap"
+)
+
+// takeRequest 占用一次任务的请求预算, 预算用完返回 false, 剩下的请求直接丢弃.
+func (c *Crawler) takeRequest(task *spider.Task) bool {
+	c.pendingLock.Lock()
+	defer c.pendingLock.Unlock()
+
+	st, ok := c.tasks[task.Name]
+	if !ok {
+		return true
+	}
+
+	if task.MaxDuration > 0 && !st.startTime.IsZero() && c.taskElapsed(st) >= task.MaxDuration {
+		c.exhaust(st, "max duration")
+	}
+	if task.MaxRequests > 0 && st.fetched >= task.MaxRequests {
+		c.exhaust(st, "max requests")
+	}
+	if st.exhausted {
+		return false
+	}
+
+	st.fetched++
+	return true
+}
+
+// taskElapsed 任务开始后的运行时长, 不包括暂停的时间. 调用方需持有 pendingLock.
+func (c *Crawler) taskElapsed(st *taskState) time.Duration {
+	return time.Since(st.startTime) - (c.pausedFor() - st.pauseBase)
+}
+
+// returnRequest 归还 takeRequest 占用的预算, 用于没有真正发起的请求.
+func (c *Crawler) returnRequest(task *spider.Task) {
+	c.pendingLock.Lock()
+	defer c.pendingLock.Unlock()
+	if st, ok := c.tasks[task.Name]; ok {
+		st.fetched--
+	}
+}
+
+// takeItem 占用一次任务的数据预算, 达到 MaxItems 后不再保存数据也不再发起请求.
+func (c *Crawler) takeItem(task *spider.Task) bool {
+	c.pendingLock.Lock()
+	defer c.pendingLock.Unlock()
+
+	st, ok := c.tasks[task.Name]
+	if !ok {
+		return true
+	}
+
+	if task.MaxItems > 0 && st.items >= task.MaxItems {
+		return false
+	}
+	st.items++
+	if task.MaxItems > 0 && st.items >= task.MaxItems {
+		c.exhaust(st, "max items")
+	}
+	return true
+}
+
+// exhaust 调用方需持有 pendingLock.
+func (c *Crawler) exhaust(st *taskState, reason string) {
+	if st.exhausted {
+		return
+	}
+	st.exhausted = true
+	c.Logger.Info("task budget exhausted",
+		zap.String("task", st.task.Name),
+		zap.String("reason", reason),
+		zap.Int64("requests", st.fetched),
+		zap.Int64("items", st.items),
+	)
+	// Drop 要等调度协程处理, 不能持有 pendingLock
+	go c.dropQueued(st.task.Name)
+}
+
+// dropQueued 丢弃预算用完的任务还在队列中的请求, 不再占用队列和断点.
+// 等待重试或者正在入队的请求在出队后由 takeRequest 丢弃.
+func (c *Crawler) dropQueued(name string) {
+	d, ok := c.scheduler.(Dropper)
+	if !ok {
+		return
+	}
+
+	dropped := d.Drop(func(req *spider.Request) bool {
+		return req.Task.Name == name
+	})
+	for _, req := range dropped {
+		atomic.AddInt64(&c.stats.dropped, 1)
+		c.finish(req)
+	}
+	if len(dropped) > 0 {
+		c.Logger.Info("drop queued requests, task budget exhausted",
+			zap.String("task", name),
+			zap.Int("count", len(dropped)))
+	}
+}
+
+// dropExhausted 过滤掉预算已经用完的任务的新请求.
+func (c *Crawler) dropExhausted(reqs []*spider.Request) []*spider.Request {
+	c.pendingLock.Lock()
+	defer c.pendingLock.Unlock()
+
+	kept := make([]*spider.Request, 0, len(reqs))
+	for _, req := range reqs {
+		if st, ok := c.tasks[req.Task.Name]; ok && st.exhausted {
+			atomic.AddInt64(&c.stats.dropped, 1)
+			continue
+		}
+		kept = append(kept, req)
+	}
+	return kept
+}
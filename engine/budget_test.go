@@ -0,0 +1,85 @@
+package engine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Ysoding/pokemon-wiki-spider/spider"
+)
+
+func TestMaxRequestsDropsQueued(t *testing.T) {
+	task := newListTask("list", "https://a/1", "https://a/2", "https://a/3", "https://a/4", "https://a/5")
+	task.MaxRequests = 2
+	fetch := &countFetch{}
+
+	e := NewEngine(
+		WithScheduler(NewFairSchedule(0)),
+		WithWorkerCount(1),
+		WithFetcher(fetch),
+		WithSeeds([]*spider.Task{task}),
+	)
+	summary, err := e.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := fetch.fetched(); len(got) != 2 {
+		t.Fatalf("fetched %v, want 2 requests", got)
+	}
+	if summary.Dropped != 3 {
+		t.Fatalf("dropped = %d, want 3", summary.Dropped)
+	}
+}
+
+func TestMaxDurationExcludesPause(t *testing.T) {
+	task := newListTask("list", "https://a/1", "https://a/2")
+	task.MaxDuration = 50 * time.Millisecond
+	fetch := &countFetch{}
+
+	e := NewEngine(
+		WithScheduler(NewSchedule()),
+		WithFetcher(fetch),
+		WithSeeds([]*spider.Task{task}),
+	)
+	e.Pause()
+	time.AfterFunc(150*time.Millisecond, e.Resume)
+
+	if _, err := e.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := fetch.fetched(); len(got) != 2 {
+		t.Fatalf("fetched %v, want both requests after resume", got)
+	}
+}
+
+func TestScheduleDrop(t *testing.T) {
+	a := &spider.Task{Options: spider.Options{Name: "a"}}
+	b := &spider.Task{Options: spider.Options{Name: "b"}}
+
+	s := NewFairSchedule(0)
+	go s.Schedule()
+	defer s.Close()
+
+	s.Push(
+		&spider.Request{URL: "a1", Task: a},
+		&spider.Request{URL: "b1", Task: b},
+		&spider.Request{URL: "a2", Task: a},
+		&spider.Request{URL: "b2", Task: b},
+	)
+	dropped := s.Drop(func(req *spider.Request) bool { return req.Task == a })
+	if len(dropped) != 2 {
+		t.Fatalf("dropped %d requests, want 2", len(dropped))
+	}
+
+	for _, want := range []string{"b1", "b2"} {
+		if req := s.Pull(); req.URL != want {
+			t.Fatalf("pulled %s, want %s", req.URL, want)
+		}
+	}
+
+	s.Close()
+	if dropped := s.Drop(func(*spider.Request) bool { return true }); dropped != nil {
+		t.Fatalf("drop after close = %v", dropped)
+	}
+}
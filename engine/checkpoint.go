@@ -0,0 +1,172 @@
+package engine
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Ysoding/pokemon-wiki-spider/checkpoint"
+	"github.com/Ysoding/pokemon-wiki-spider/dedup"
+	"github.com/Ysoding/pokemon-wiki-spider/spider"
+	"go.uber.org/zap"
+)
+
+func (c *Crawler) snapshot() *checkpoint.Snapshot {
+	s := &checkpoint.Snapshot{Time: time.Now()}
+
+	c.pendingLock.Lock()
+	for req := range c.pending {
+		s.Pending = append(s.Pending, req.Record())
+	}
+	for _, st := range c.taskOrder {
+		if st.finished && !st.failed {
+			s.Finished = append(s.Finished, st.task.Name)
+		}
+	}
+	c.pendingLock.Unlock()
+
+	// 只保存 engine 的访问记录, 任务自己的 Dedup 需要自行持久化
+	if l, ok := c.Dedup.(dedup.Lister); ok {
+		s.Visited = l.Keys()
+	}
+
+	c.failuresLock.Lock()
+	for _, req := range c.failures {
+		s.Failures = append(s.Failures, req.Record())
+	}
+	c.failuresLock.Unlock()
+
+	return s
+}
+
+// saveCheckpoint 先 flush storage 再把访问记录落盘, 期间不保存新的数据.
+func (c *Crawler) saveCheckpoint() {
+	c.checkpointLock.Lock()
+	defer c.checkpointLock.Unlock()
+
+	c.flushStorage()
+	c.syncDedup()
+	s := c.snapshot()
+	if err := c.Checkpoint.Save(s); err != nil {
+		c.Logger.Error("save checkpoint failed", zap.Error(err))
+		return
+	}
+	c.Logger.Info("checkpoint saved",
+		zap.Int("pending", len(s.Pending)),
+		zap.Int("visited", len(s.Visited)),
+		zap.Int("failures", len(s.Failures)),
+	)
+}
+
+// syncDedup 把需要落盘的访问记录写到磁盘, 例如 bloom 过滤器, 包括任务自己的 Dedup.
+func (c *Crawler) syncDedup() {
+	stores := []dedup.Store{c.Dedup}
+	c.pendingLock.Lock()
+	for _, st := range c.taskOrder {
+		stores = append(stores, st.task.Dedup)
+	}
+	c.pendingLock.Unlock()
+
+	synced := make(map[dedup.Store]bool)
+	for _, s := range stores {
+		syncer, ok := s.(dedup.Syncer)
+		if !ok || synced[s] {
+			continue
+		}
+		synced[s] = true
+		if err := syncer.Sync(); err != nil {
+			c.Logger.Error("sync dedup store failed", zap.Error(err))
+		}
+	}
+}
+
+func (c *Crawler) runCheckpoint() {
+	ticker := time.NewTicker(c.CheckpointInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.saveCheckpoint()
+		case <-c.stopped:
+			return
+		}
+	}
+}
+
+// restoreCheckpoint 恢复访问记录, 失败请求和任务进度, 由 initTasks 调用.
+// 有未完成请求的任务直接从这些请求继续, 不在本次选择中的任务也会加入, 和重放的请求一样不执行 Root.
+// 已完成的任务不再执行, 其余任务照常从 Root 开始.
+func (c *Crawler) restoreCheckpoint() error {
+	if !c.options.Resume || c.Checkpoint == nil {
+		return nil
+	}
+
+	s, err := c.Checkpoint.Load()
+	if err != nil {
+		return fmt.Errorf("load checkpoint: %w", err)
+	}
+	if s == nil {
+		c.Logger.Info("no checkpoint found, start from root")
+		return nil
+	}
+
+	toRequests := func(records []spider.Record) ([]*spider.Request, error) {
+		reqs := make([]*spider.Request, 0, len(records))
+		for _, rec := range records {
+			var task *spider.Task
+			if st, ok := c.tasks[rec.Task]; ok {
+				task = st.task
+			} else if task = spider.LookupTask(rec.Task); task == nil {
+				return nil, fmt.Errorf("checkpoint: unknown task %q for %s", rec.Task, rec.URL)
+			}
+			reqs = append(reqs, rec.Request(task))
+		}
+		return reqs, nil
+	}
+
+	pending, err := toRequests(s.Pending)
+	if err != nil {
+		return err
+	}
+
+	failures, err := toRequests(s.Failures)
+	if err != nil {
+		return err
+	}
+
+	c.failuresLock.Lock()
+	for _, req := range failures {
+		c.failures[req.Unique()] = req
+	}
+	c.failuresLock.Unlock()
+
+	for _, k := range s.Visited {
+		if err := c.Dedup.Add(k); err != nil {
+			return fmt.Errorf("restore visited: %w", err)
+		}
+	}
+	// 断点中未完成的请求还没有写入访问记录, 作为直接入队的请求跳过去重重新抓
+	for _, req := range pending {
+		req.Attempt = 0
+
+		st := c.addTask(req.Task)
+		st.resumed = true
+		st.requests = append(st.requests, req)
+	}
+
+	for _, name := range s.Finished {
+		if st, ok := c.tasks[name]; ok && !st.resumed {
+			st.started, st.finished = true, true
+		}
+	}
+
+	c.Logger.Info("resume from checkpoint",
+		zap.Time("time", s.Time),
+		zap.Int("pending", len(pending)),
+		zap.Int("visited", len(s.Visited)),
+		zap.Int("failures", len(failures)),
+		zap.Strings("finished", s.Finished),
+	)
+
+	return nil
+}
@@ -0,0 +1,148 @@
+package engine
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/Ysoding/pokemon-wiki-spider/checkpoint"
+	"github.com/Ysoding/pokemon-wiki-spider/dedup"
+	"github.com/Ysoding/pokemon-wiki-spider/spider"
+)
+
+// memCheckpoint 断点保存在内存中.
+type memCheckpoint struct {
+	snapshot *checkpoint.Snapshot
+}
+
+func (s *memCheckpoint) Save(snapshot *checkpoint.Snapshot) error {
+	s.snapshot = snapshot
+	return nil
+}
+
+func (s *memCheckpoint) Load() (*checkpoint.Snapshot, error) {
+	return s.snapshot, nil
+}
+
+func TestResumeTaskOutsideSelection(t *testing.T) {
+	other := newListTask("resume_other", "https://b/1", "https://b/2")
+	spider.Register(other)
+
+	store := &memCheckpoint{snapshot: &checkpoint.Snapshot{
+		Pending: []spider.Record{{Task: other.Name, URL: "https://b/2", RuleName: "parse", Attempt: 2}},
+	}}
+	fetch := &countFetch{}
+
+	// 本次只选了 list, 断点里还有 resume_other 没抓完的请求
+	e := NewEngine(
+		WithScheduler(NewSchedule()),
+		WithFetcher(fetch),
+		WithCheckpoint(store, 0),
+		WithResume(true),
+		WithSeeds([]*spider.Task{newListTask("list", "https://a/1")}),
+	)
+	if _, err := e.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	got := map[string]bool{}
+	for _, u := range fetch.fetched() {
+		got[u] = true
+	}
+	if len(got) != 2 || !got["https://a/1"] || !got["https://b/2"] {
+		t.Fatalf("fetched %v, want list root and the pending request without running its Root", fetch.fetched())
+	}
+}
+
+func TestCheckpointRoundTrip(t *testing.T) {
+	list := newListTask("list", "https://a/1")
+	detail := newListTask("detail", "https://a/detail")
+	store := checkpoint.NewFileStore(filepath.Join(t.TempDir(), "checkpoint.json"))
+	seeds := []*spider.Task{list, detail}
+
+	// 第一次运行到一半: detail 有排队的请求和失败的请求, list 已经完成
+	before := NewEngine(WithCheckpoint(store, 0), WithSeeds(seeds))
+	before.pendingLock.Lock()
+	if err := before.initTasks(); err != nil {
+		t.Fatal(err)
+	}
+	before.pendingLock.Unlock()
+
+	pending := &spider.Request{URL: "https://a/detail/2", RuleName: "parse", Task: detail, Depth: 1, Attempt: 2}
+	failed := &spider.Request{URL: "https://a/detail/3", RuleName: "parse", Task: detail, Attempt: 3}
+	visited := &spider.Request{URL: "https://a/detail/1", RuleName: "parse", Task: detail}
+	before.addPending(pending)
+	before.failures[failed.Unique()] = failed
+	if err := before.Dedup.Add(visited.Unique()); err != nil {
+		t.Fatal(err)
+	}
+	before.tasks[list.Name].finished = true
+	before.saveCheckpoint()
+
+	after := NewEngine(WithCheckpoint(store, 0), WithResume(true), WithSeeds(seeds))
+	after.pendingLock.Lock()
+	err := after.initTasks()
+	after.pendingLock.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	st := after.tasks[detail.Name]
+	if !st.resumed || len(st.requests) != 1 {
+		t.Fatalf("detail resumed = %v, requests = %d", st.resumed, len(st.requests))
+	}
+	if req := st.requests[0]; req.URL != pending.URL || req.Depth != 1 || req.Attempt != 0 || req.Task != detail {
+		t.Fatalf("pending request = %+v", req)
+	}
+	if has, _ := after.Dedup.Has(visited.Unique()); !has {
+		t.Fatal("visited key lost")
+	}
+	if req, ok := after.failures[failed.Unique()]; !ok || req.Attempt != 3 {
+		t.Fatalf("failure lost: %+v", req)
+	}
+	if st := after.tasks[list.Name]; !st.finished || !st.started {
+		t.Fatal("finished task will run again")
+	}
+}
+
+// orderLog 记录 flush 和 sync 的先后.
+type orderLog []string
+
+type logStorage struct{ log *orderLog }
+
+func (s logStorage) Save(datas ...*spider.DataCell) error { return nil }
+
+func (s logStorage) Flush() error {
+	*s.log = append(*s.log, "flush")
+	return nil
+}
+
+type logDedup struct {
+	*dedup.Memory
+	log *orderLog
+}
+
+func (s logDedup) Sync() error {
+	*s.log = append(*s.log, "sync")
+	return nil
+}
+
+func TestCheckpointFlushesBeforeSync(t *testing.T) {
+	var log orderLog
+	e := NewEngine(
+		WithCheckpoint(&memCheckpoint{}, 0),
+		WithStorage(logStorage{&log}),
+		WithDedup(logDedup{dedup.NewMemory(), &log}),
+	)
+	e.pendingLock.Lock()
+	if err := e.initTasks(); err != nil {
+		t.Fatal(err)
+	}
+	e.pendingLock.Unlock()
+
+	// 访问记录落盘前, 对应的数据必须已经 flush
+	e.saveCheckpoint()
+	if len(log) != 2 || log[0] != "flush" || log[1] != "sync" {
+		t.Fatalf("order = %v, want flush before sync", log)
+	}
+}
@@ -0,0 +1,169 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// Status 抓取的实时状态.
+type Status struct {
+	State    string
+	Paused   bool
+	Queued   int   // 在队列中或者等待重试的请求
+	InFlight int64 // 正在处理的请求
+	// 每个保存协程中等待保存的结果数
+	ResultQueue []int
+	Failures    int
+	Summary     Summary
+	Tasks       []TaskStatus
+}
+
+type TaskStatus struct {
+	Name     string
+	State    string // waiting, running, finished, failed
+	Pending  int
+	Requests int64
+	Items    int64
+	Failures int
+	// 不符合 Rule.ItemFields 的数据条数
+	Violations int64
+}
+
+// Pause 暂停发起新的请求, 正在处理的请求继续完成, 队列保留在内存中.
+func (c *Crawler) Pause() {
+	c.pauseLock.Lock()
+	defer c.pauseLock.Unlock()
+	if c.paused != nil {
+		return
+	}
+	c.paused = make(chan struct{})
+	c.pausedAt = time.Now()
+	c.Logger.Info("crawler paused")
+}
+
+// Resume 继续被 Pause 暂停的抓取.
+func (c *Crawler) Resume() {
+	c.pauseLock.Lock()
+	defer c.pauseLock.Unlock()
+	if c.paused == nil {
+		return
+	}
+	close(c.paused)
+	c.paused = nil
+	c.pauseTime += time.Since(c.pausedAt)
+	c.Logger.Info("crawler resumed")
+}
+
+// pausedFor 到现在为止暂停的总时长, 包括正在进行的暂停.
+func (c *Crawler) pausedFor() time.Duration {
+	c.pauseLock.Lock()
+	defer c.pauseLock.Unlock()
+	d := c.pauseTime
+	if c.paused != nil {
+		d += time.Since(c.pausedAt)
+	}
+	return d
+}
+
+// waitResume 暂停时阻塞到 Resume 或 ctx 结束.
+func (c *Crawler) waitResume(ctx context.Context) error {
+	c.pauseLock.Lock()
+	paused := c.paused
+	c.pauseLock.Unlock()
+	if paused == nil {
+		return nil
+	}
+
+	select {
+	case <-paused:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *Crawler) Status() Status {
+	s := Status{
+		State:       c.State().String(),
+		InFlight:    atomic.LoadInt64(&c.inFlight),
+		ResultQueue: c.results.depth(),
+		Summary:     c.stats.summary(),
+	}
+
+	c.pauseLock.Lock()
+	s.Paused = c.paused != nil
+	c.pauseLock.Unlock()
+
+	failures := make(map[string]int)
+	c.failuresLock.Lock()
+	for _, req := range c.failures {
+		failures[req.Task.Name]++
+	}
+	s.Failures = len(c.failures)
+	c.failuresLock.Unlock()
+
+	c.pendingLock.Lock()
+	s.Queued = c.pendingCnt - int(s.InFlight)
+	for _, st := range c.taskOrder {
+		ts := TaskStatus{
+			Name:       st.task.Name,
+			State:      "waiting",
+			Pending:    st.pending,
+			Requests:   st.fetched,
+			Items:      st.items,
+			Failures:   failures[st.task.Name],
+			Violations: st.violations,
+		}
+		switch {
+		case st.failed:
+			ts.State = "failed"
+		case st.finished:
+			ts.State = "finished"
+		case st.started:
+			ts.State = "running"
+		}
+		s.Tasks = append(s.Tasks, ts)
+	}
+	c.pendingLock.Unlock()
+	if s.Queued < 0 {
+		s.Queued = 0
+	}
+
+	return s
+}
+
+// ControlHandler 本地控制接口:
+//
+//	GET  /status  查看状态
+//	POST /pause   暂停
+//	POST /resume  继续
+func (c *Crawler) ControlHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(c.Status()); err != nil {
+			c.Logger.Sugar().Errorw("control", "encode status", err)
+		}
+	})
+	mux.HandleFunc("/pause", c.controlAction(c.Pause))
+	mux.HandleFunc("/resume", c.controlAction(c.Resume))
+	return mux
+}
+
+func (c *Crawler) controlAction(action func()) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		action()
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
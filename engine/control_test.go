@@ -0,0 +1,127 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Ysoding/pokemon-wiki-spider/spider"
+)
+
+func TestControlHandler(t *testing.T) {
+	e := NewEngine(WithSeeds([]*spider.Task{newListTask("list", "https://a/1")}))
+	h := e.ControlHandler()
+
+	do := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+	paused := func() bool {
+		t.Helper()
+		w := do(http.MethodGet, "/status")
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET /status = %d", w.Code)
+		}
+		if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+			t.Fatalf("Content-Type = %q", ct)
+		}
+		var s Status
+		if err := json.NewDecoder(w.Body).Decode(&s); err != nil {
+			t.Fatal(err)
+		}
+		return s.Paused
+	}
+
+	if paused() {
+		t.Fatal("paused before POST /pause")
+	}
+	if w := do(http.MethodPost, "/pause"); w.Code != http.StatusNoContent {
+		t.Fatalf("POST /pause = %d", w.Code)
+	}
+	if !paused() {
+		t.Fatal("not paused after POST /pause")
+	}
+	if w := do(http.MethodPost, "/resume"); w.Code != http.StatusNoContent {
+		t.Fatalf("POST /resume = %d", w.Code)
+	}
+	if paused() {
+		t.Fatal("still paused after POST /resume")
+	}
+
+	// 方法不对时拒绝, 也不改变状态
+	for _, tt := range []struct{ method, path string }{
+		{http.MethodPost, "/status"},
+		{http.MethodGet, "/pause"},
+		{http.MethodGet, "/resume"},
+		{http.MethodPut, "/pause"},
+	} {
+		if w := do(tt.method, tt.path); w.Code != http.StatusMethodNotAllowed {
+			t.Errorf("%s %s = %d, want %d", tt.method, tt.path, w.Code, http.StatusMethodNotAllowed)
+		}
+	}
+	if paused() {
+		t.Fatal("GET /pause paused the crawler")
+	}
+}
+
+// pauseFetch 抓第一个请求时暂停抓取.
+type pauseFetch struct {
+	countFetch
+	once  sync.Once
+	pause func()
+}
+
+func (f *pauseFetch) Get(ctx context.Context, req *spider.Request) (*spider.Response, error) {
+	f.once.Do(f.pause)
+	return f.countFetch.Get(ctx, req)
+}
+
+func TestPauseStopsWorkers(t *testing.T) {
+	task := newListTask("list", "https://a/1", "https://a/2", "https://a/3")
+	fetch := &pauseFetch{}
+	e := NewEngine(
+		WithScheduler(NewSchedule()),
+		WithWorkerCount(1),
+		WithFetcher(fetch),
+		WithSeeds([]*spider.Task{task}),
+	)
+	fetch.pause = e.Pause
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := e.Run(context.Background())
+		done <- err
+	}()
+
+	// 暂停期间 worker 不再取新的请求
+	time.Sleep(200 * time.Millisecond)
+	if got := fetch.fetched(); len(got) != 1 {
+		t.Fatalf("fetched %v while paused, want only the first request", got)
+	}
+	if !e.Status().Paused {
+		t.Fatal("status not paused")
+	}
+	select {
+	case err := <-done:
+		t.Fatalf("Run returned while paused: %v", err)
+	default:
+	}
+
+	e.Resume()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not finish after Resume")
+	}
+	if got := fetch.fetched(); len(got) != 3 {
+		t.Fatalf("fetched %v, want all requests after resume", got)
+	}
+}
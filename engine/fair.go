@@ -0,0 +1,129 @@
+package engine
+
+import (
+	"time"
+
+	"github.com/Ysoding/pokemon-wiki-spider/spider"
+)
+
+// FairSchedule 每个 spider.Task 一个子队列, 按 Options.Weight 加权轮询,
+// 避免根请求很多的任务饿死其他任务. 子队列内部按优先级调度.
+type FairSchedule struct {
+	s *Schedule
+}
+
+// NewFairSchedule aging 含义同 NewPrioritySchedule.
+func NewFairSchedule(aging time.Duration) *FairSchedule {
+	return &FairSchedule{
+		s: newSchedule(newFairQueue(aging)),
+	}
+}
+
+func (f *FairSchedule) Schedule() {
+	f.s.Schedule()
+}
+
+func (f *FairSchedule) Push(requests ...*spider.Request) error {
+	return f.s.Push(requests...)
+}
+
+func (f *FairSchedule) Pull() *spider.Request {
+	return f.s.Pull()
+}
+
+func (f *FairSchedule) Close() {
+	f.s.Close()
+}
+
+func (f *FairSchedule) Drop(match func(*spider.Request) bool) []*spider.Request {
+	return f.s.Drop(match)
+}
+
+type taskQueue struct {
+	*priorityQueue
+	weight  int
+	current int // 队列变空时清零, 重新有请求时不会带着之前积累的份额连续出队
+}
+
+// fairQueue 平滑加权轮询 (smooth weighted round-robin), 只在非空的子队列之间分配.
+type fairQueue struct {
+	aging  time.Duration
+	queues map[*spider.Task]*taskQueue
+	order  []*taskQueue // 保证遍历顺序稳定
+	size   int
+}
+
+func newFairQueue(aging time.Duration) *fairQueue {
+	return &fairQueue{
+		aging:  aging,
+		queues: make(map[*spider.Task]*taskQueue),
+	}
+}
+
+func (q *fairQueue) Push(req *spider.Request) {
+	tq, ok := q.queues[req.Task]
+	if !ok {
+		weight := 1
+		if req.Task != nil && req.Task.Weight > 0 {
+			weight = req.Task.Weight
+		}
+		tq = &taskQueue{priorityQueue: newPriorityQueue(q.aging), weight: weight}
+		q.queues[req.Task] = tq
+		q.order = append(q.order, tq)
+	}
+	tq.Push(req)
+	q.size++
+}
+
+// next 选出下一个出队的子队列, 不修改状态, 和 Pop 的选择一致.
+func (q *fairQueue) next() *taskQueue {
+	var best *taskQueue
+	for _, tq := range q.order {
+		if tq.Len() == 0 {
+			continue
+		}
+		if best == nil || tq.current+tq.weight > best.current+best.weight {
+			best = tq
+		}
+	}
+	return best
+}
+
+func (q *fairQueue) Peek() *spider.Request {
+	return q.next().Peek()
+}
+
+func (q *fairQueue) Pop() *spider.Request {
+	best := q.next()
+	total := 0
+	for _, tq := range q.order {
+		if tq.Len() == 0 {
+			continue
+		}
+		tq.current += tq.weight
+		total += tq.weight
+	}
+	best.current -= total
+	q.size--
+	req := best.Pop()
+	if best.Len() == 0 {
+		best.current = 0
+	}
+	return req
+}
+
+func (q *fairQueue) Len() int {
+	return q.size
+}
+
+func (q *fairQueue) Remove(match func(*spider.Request) bool) []*spider.Request {
+	var removed []*spider.Request
+	for _, tq := range q.order {
+		removed = append(removed, tq.Remove(match)...)
+		if tq.Len() == 0 {
+			tq.current = 0
+		}
+	}
+	q.size -= len(removed)
+	return removed
+}
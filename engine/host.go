@@ -0,0 +1,64 @@
+package engine
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// hostLimiter 限制每个 host 同时在途的请求数, 和任务的限流器互相独立.
+type hostLimiter struct {
+	max    int            // 没有单独配置的 host 的上限, <= 0 不限制
+	limits map[string]int // host -> 上限
+	mu     sync.Mutex
+	sems   map[string]chan struct{}
+}
+
+func newHostLimiter(max int, limits map[string]int) *hostLimiter {
+	return &hostLimiter{
+		max:    max,
+		limits: limits,
+		sems:   make(map[string]chan struct{}),
+	}
+}
+
+func (h *hostLimiter) sem(host string) chan struct{} {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if sem, ok := h.sems[host]; ok {
+		return sem
+	}
+
+	n, ok := h.limits[host]
+	if !ok {
+		n = h.max
+	}
+	var sem chan struct{}
+	if n > 0 {
+		sem = make(chan struct{}, n)
+	}
+	h.sems[host] = sem
+	return sem
+}
+
+// acquire 等待 rawURL 所在 host 的空位, 返回的 release 必须调用.
+func (h *hostLimiter) acquire(ctx context.Context, rawURL string) (release func(), err error) {
+	host := ""
+	if u, err := url.Parse(rawURL); err == nil {
+		host = strings.ToLower(u.Hostname())
+	}
+
+	sem := h.sem(host)
+	if sem == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case sem <- struct{}{}:
+		return func() { <-sem }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,45 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestHostLimiter(t *testing.T) {
+	h := newHostLimiter(1, map[string]int{"wiki.52poke.com": 2})
+
+	release, err := h.acquire(context.Background(), "https://example.com/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 同一个 host 没有空位, 大小写和端口不影响
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := h.acquire(ctx, "https://EXAMPLE.com:443/b"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("second acquire err = %v, want deadline exceeded", err)
+	}
+
+	// 其他 host 不受影响, 单独配置的 host 按自己的上限
+	for i := 0; i < 2; i++ {
+		if _, err := h.acquire(context.Background(), "https://wiki.52poke.com/wiki/x"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	release()
+	if _, err := h.acquire(context.Background(), "https://example.com/b"); err != nil {
+		t.Fatalf("acquire after release: %v", err)
+	}
+}
+
+func TestHostLimiterUnlimited(t *testing.T) {
+	h := newHostLimiter(0, nil)
+	for i := 0; i < 100; i++ {
+		if _, err := h.acquire(context.Background(), "https://example.com"); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
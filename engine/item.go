@@ -0,0 +1,80 @@
+package engine
+
+import (
+	"sync/atomic"
+
+	"github.com/Ysoding/pokemon-wiki-spider/spider"
+	"go.uber.org/zap"
+)
+
+// processItem 依次执行 engine 和任务的处理器, 处理器出错时丢弃这条数据.
+func (c *Crawler) processItem(item *spider.DataCell) []*spider.DataCell {
+	processors := c.Processors
+	if len(item.Task.Processors) > 0 {
+		processors = append(processors[:len(processors):len(processors)], item.Task.Processors...)
+	}
+
+	items := []*spider.DataCell{item}
+	for _, p := range processors {
+		var next []*spider.DataCell
+		for _, d := range items {
+			out, err := p(d)
+			if err != nil {
+				c.Logger.Error("item processor failed, drop item",
+					zap.String("task", d.Task.Name),
+					zap.Error(err))
+				continue
+			}
+			// 额外产出的数据默认和原数据同一个任务和来源
+			for _, o := range out {
+				if o.Task == nil {
+					o.Task = d.Task
+				}
+				if o.Meta.Task == "" {
+					o.Meta.Task = d.Meta.Task
+				}
+				if o.Meta.URL == "" {
+					o.Meta.URL, o.Meta.Rule, o.Meta.FetchedAt = d.Meta.URL, d.Meta.Rule, d.Meta.FetchedAt
+				}
+			}
+			next = append(next, out...)
+		}
+		if items = next; len(items) == 0 {
+			break
+		}
+	}
+	return items
+}
+
+// validateItem 按规则的 ItemFields 校验数据, 违反时计数并记录日志.
+func (c *Crawler) validateItem(d *spider.DataCell) bool {
+	schema := d.Schema()
+	if len(schema) == 0 {
+		return true
+	}
+
+	var errs []error
+	fields, err := d.Fields()
+	if err != nil {
+		errs = append(errs, err)
+	} else {
+		errs = schema.Validate(fields)
+	}
+	if len(errs) == 0 {
+		return true
+	}
+
+	atomic.AddInt64(&c.stats.violations, 1)
+	c.pendingLock.Lock()
+	if st, ok := c.tasks[d.Task.Name]; ok {
+		st.violations++
+	}
+	c.pendingLock.Unlock()
+
+	c.Logger.Warn("item schema violation",
+		zap.String("task", d.Task.Name),
+		zap.String("rule", d.Meta.Rule),
+		zap.String("url", d.Meta.URL),
+		zap.Errors("errors", errs))
+	return false
+}
@@ -0,0 +1,77 @@
+package engine
+
+import (
+	"context"
+
+	"github.com/Ysoding/pokemon-wiki-spider/spider"
+)
+
+func (c *Crawler) middlewares(task *spider.Task) []spider.Middleware {
+	if len(task.Middlewares) == 0 {
+		return c.Middlewares
+	}
+	mws := make([]spider.Middleware, 0, len(c.Middlewares)+len(task.Middlewares))
+	mws = append(mws, c.Middlewares...)
+	return append(mws, task.Middlewares...)
+}
+
+// onRequest 有中间件返回响应时, 后面的 OnRequest 不再调用.
+func (c *Crawler) onRequest(ctx context.Context, req *spider.Request) (*spider.Response, error) {
+	for _, mw := range c.middlewares(req.Task) {
+		if mw.OnRequest == nil {
+			continue
+		}
+		resp, err := mw.OnRequest(ctx, req)
+		if err != nil || resp != nil {
+			return resp, err
+		}
+	}
+	return nil, nil
+}
+
+func (c *Crawler) onResponse(req *spider.Request, resp *spider.Response) error {
+	for _, mw := range c.middlewares(req.Task) {
+		if mw.OnResponse == nil {
+			continue
+		}
+		if err := mw.OnResponse(req, resp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Crawler) onError(req *spider.Request, err error) {
+	for _, mw := range c.middlewares(req.Task) {
+		if mw.OnError != nil {
+			mw.OnError(req, err)
+		}
+	}
+}
+
+func (c *Crawler) onItems(req *spider.Request, items []interface{}) []interface{} {
+	mws := c.middlewares(req.Task)
+	out := items[:0]
+	for _, item := range items {
+		for _, mw := range mws {
+			if mw.OnItem == nil {
+				continue
+			}
+			if item = mw.OnItem(req, item); item == nil {
+				break
+			}
+		}
+		if item != nil {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+func (c *Crawler) onParseError(req *spider.Request, err error) {
+	for _, mw := range c.middlewares(req.Task) {
+		if mw.OnParseError != nil {
+			mw.OnParseError(req, err)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/Ysoding/pokemon-wiki-spider/spider"
+)
+
+// hookLog 按调用顺序记录中间件钩子.
+type hookLog struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (l *hookLog) add(call string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.calls = append(l.calls, call)
+}
+
+func (l *hookLog) middleware(name string) spider.Middleware {
+	return spider.Middleware{
+		Name: name,
+		OnRequest: func(ctx context.Context, req *spider.Request) (*spider.Response, error) {
+			l.add(name + ".request")
+			return nil, nil
+		},
+		OnResponse: func(req *spider.Request, resp *spider.Response) error {
+			l.add(name + ".response")
+			return nil
+		},
+		OnError: func(req *spider.Request, err error) {
+			l.add(name + ".error")
+		},
+		OnItem: func(req *spider.Request, item interface{}) interface{} {
+			l.add(name + ".item")
+			return item
+		},
+		OnParseError: func(req *spider.Request, err error) {
+			l.add(name + ".parse_error")
+		},
+	}
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	log := &hookLog{}
+	task := newListTask("list", "https://a/1")
+	task.Middlewares = []spider.Middleware{log.middleware("task")}
+	task.Rule.Trunk["parse"].ParseFunc = func(ctx *spider.Context) (spider.ParseResult, error) {
+		return spider.ParseResult{Items: []interface{}{ctx.Output(ctx.Req.URL)}}, nil
+	}
+
+	e := NewEngine(
+		WithScheduler(NewSchedule()),
+		WithFetcher(&countFetch{}),
+		WithStorage(logStorage{&orderLog{}}),
+		WithMiddleware(log.middleware("first")),
+		WithMiddleware(log.middleware("second")),
+		WithSeeds([]*spider.Task{task}),
+	)
+	if _, err := e.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// engine 的中间件按注册顺序, 然后才是任务的中间件
+	want := []string{
+		"first.request", "second.request", "task.request",
+		"first.response", "second.response", "task.response",
+		"first.item", "second.item", "task.item",
+	}
+	if !reflect.DeepEqual(log.calls, want) {
+		t.Fatalf("calls = %v, want %v", log.calls, want)
+	}
+}
+
+func TestMiddlewareParseErrorOrder(t *testing.T) {
+	log := &hookLog{}
+	task := newListTask("list", "https://a/1")
+	task.Middlewares = []spider.Middleware{log.middleware("task")}
+	task.Rule.Trunk["parse"].ParseFunc = func(ctx *spider.Context) (spider.ParseResult, error) {
+		return spider.ParseResult{}, errors.New("bad page")
+	}
+
+	e := NewEngine(
+		WithScheduler(NewSchedule()),
+		WithFetcher(&countFetch{}),
+		WithMiddleware(log.middleware("engine")),
+		WithSeeds([]*spider.Task{task}),
+	)
+	if _, err := e.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// OnParseError 先于 OnError, 都不调用 OnItem
+	want := []string{
+		"engine.request", "task.request",
+		"engine.response", "task.response",
+		"engine.parse_error", "task.parse_error",
+		"engine.error", "task.error",
+	}
+	if !reflect.DeepEqual(log.calls, want) {
+		t.Fatalf("calls = %v, want %v", log.calls, want)
+	}
+}
+
+// headerFetch 记录每个请求抓取时的 header.
+type headerFetch struct {
+	mu      sync.Mutex
+	headers map[string]http.Header
+}
+
+func (f *headerFetch) Get(ctx context.Context, req *spider.Request) (*spider.Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.headers[req.URL] = req.Header.Clone()
+	return &spider.Response{StatusCode: http.StatusOK}, nil
+}
+
+func TestOnRequestModifiesAndSkips(t *testing.T) {
+	task := newListTask("list", "https://a/1", "https://a/skip", "https://a/cached")
+	var parsed sync.Map
+	task.Rule.Trunk["parse"].ParseFunc = func(ctx *spider.Context) (spider.ParseResult, error) {
+		parsed.Store(ctx.Req.URL, string(ctx.Body))
+		return spider.ParseResult{}, nil
+	}
+
+	var later sync.Map
+	fetch := &headerFetch{headers: map[string]http.Header{}}
+	e := NewEngine(
+		WithScheduler(NewSchedule()),
+		WithFetcher(fetch),
+		WithMiddleware(
+			spider.Middleware{
+				Name: "modify",
+				OnRequest: func(ctx context.Context, req *spider.Request) (*spider.Response, error) {
+					switch req.URL {
+					case "https://a/skip":
+						return nil, errors.New("blocked")
+					case "https://a/cached":
+						return &spider.Response{StatusCode: http.StatusOK, Body: []byte("cached")}, nil
+					}
+					if req.Header == nil {
+						req.Header = http.Header{}
+					}
+					req.Header.Set("X-Test", "1")
+					return nil, nil
+				},
+			},
+			spider.Middleware{
+				Name: "later",
+				OnRequest: func(ctx context.Context, req *spider.Request) (*spider.Response, error) {
+					later.Store(req.URL, true)
+					return nil, nil
+				},
+			},
+		),
+		WithSeeds([]*spider.Task{task}),
+	)
+	summary, err := e.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 修改后的请求才交给 Fetcher
+	if len(fetch.headers) != 1 || fetch.headers["https://a/1"].Get("X-Test") != "1" {
+		t.Fatalf("fetched %v, want only https://a/1 with X-Test", fetch.headers)
+	}
+	if _, ok := parsed.Load("https://a/skip"); ok {
+		t.Fatal("skipped request was parsed")
+	}
+	if summary.Dropped != 1 || summary.Failed != 0 {
+		t.Fatalf("summary = %+v, want the skipped request dropped", summary)
+	}
+
+	// 中间件返回的响应直接解析, 后面的 OnRequest 不再调用
+	if body, _ := parsed.Load("https://a/cached"); body != "cached" {
+		t.Fatalf("cached body = %v", body)
+	}
+	if _, ok := later.Load("https://a/cached"); ok {
+		t.Fatal("OnRequest after the cache hit was called")
+	}
+	if _, ok := later.Load("https://a/skip"); ok {
+		t.Fatal("OnRequest after the skip was called")
+	}
+	if _, ok := later.Load("https://a/1"); !ok {
+		t.Fatal("later OnRequest not called")
+	}
+}
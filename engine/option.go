@@ -1,6 +1,12 @@
 package engine
 
 import (
+	"strings"
+	"time"
+
+	"github.com/Ysoding/pokemon-wiki-spider/checkpoint"
+	"github.com/Ysoding/pokemon-wiki-spider/deadletter"
+	"github.com/Ysoding/pokemon-wiki-spider/dedup"
 	"github.com/Ysoding/pokemon-wiki-spider/global"
 	"github.com/Ysoding/pokemon-wiki-spider/spider"
 	"go.uber.org/zap"
@@ -13,13 +19,45 @@ type options struct {
 	Fetcher     spider.Fetcher
 	Storage     spider.Storage
 	Seeds       []*spider.Task
+	Requests    []*spider.Request // 直接入队的请求, 例如重放的失败请求
 	scheduler   Scheduler
 	Logger      *zap.Logger
+	RetryPolicy *spider.RetryPolicy
+	Validator   spider.Validator
+	DeadLetter  deadletter.Store
+	// 为 nil 时每次运行使用新的内存去重
+	Dedup dedup.Store
+	// 每个 host 同时在途的请求数, <= 0 不限制, HostLimits 中的 host 按单独的上限
+	MaxPerHost int
+	HostLimits map[string]int
+	// 先于任务自己的中间件调用
+	Middlewares []spider.Middleware
+	// 先于任务自己的处理器执行
+	Processors []spider.ItemProcessor
+	// 保存结果的并发数, 同一个任务的结果总是由同一个协程按顺序保存
+	ResultWorkers int
+	// 每个保存协程的缓冲大小, 存满后 worker 等待
+	ResultBuffer int
+	// 丢弃不符合 Rule.ItemFields 的数据, 默认只计数并记录日志
+	StrictSchema bool
+	// 停止时等待 worker 退出和保存结果的最长时间, <= 0 一直等
+	ShutdownTimeout time.Duration
+
+	Checkpoint         checkpoint.Store
+	CheckpointInterval time.Duration
+	Resume             bool // 从 Checkpoint 的断点恢复
 }
 
 var defaultOptions = options{
-	WorkerCount: global.DefaultWorkerCount,
-	Logger:      zap.NewNop(),
+	WorkerCount:        global.DefaultWorkerCount,
+	MaxPerHost:         global.DefaultMaxPerHost,
+	ResultWorkers:      global.DefaultResultWorkers,
+	ResultBuffer:       global.DefaultResultBuffer,
+	ShutdownTimeout:    global.DefaultShutdownTimeout,
+	Logger:             zap.NewNop(),
+	RetryPolicy:        &spider.DefaultRetryPolicy,
+	Validator:          spider.DefaultValidator,
+	CheckpointInterval: global.DefaultCheckpointInterval,
 }
 
 func WithLogger(l *zap.Logger) Option {
@@ -46,12 +84,107 @@ func WithSeeds(seeds []*spider.Task) Option {
 	}
 }
 
+func WithRequests(reqs []*spider.Request) Option {
+	return func(opts *options) {
+		opts.Requests = reqs
+	}
+}
+
+func WithDeadLetter(store deadletter.Store) Option {
+	return func(opts *options) {
+		opts.DeadLetter = store
+	}
+}
+
+func WithCheckpoint(store checkpoint.Store, interval time.Duration) Option {
+	return func(opts *options) {
+		opts.Checkpoint = store
+		if interval > 0 {
+			opts.CheckpointInterval = interval
+		}
+	}
+}
+
+func WithResume(resume bool) Option {
+	return func(opts *options) {
+		opts.Resume = resume
+	}
+}
+
+func WithDedup(store dedup.Store) Option {
+	return func(opts *options) {
+		opts.Dedup = store
+	}
+}
+
+func WithMaxPerHost(n int) Option {
+	return func(opts *options) {
+		opts.MaxPerHost = n
+	}
+}
+
+// WithHostLimit 单独设置 host 的在途请求上限, host 不含端口.
+func WithHostLimit(host string, n int) Option {
+	return func(opts *options) {
+		limits := make(map[string]int, len(opts.HostLimits)+1)
+		for k, v := range opts.HostLimits {
+			limits[k] = v
+		}
+		limits[strings.ToLower(host)] = n
+		opts.HostLimits = limits
+	}
+}
+
+func WithMiddleware(mws ...spider.Middleware) Option {
+	return func(opts *options) {
+		opts.Middlewares = append(opts.Middlewares, mws...)
+	}
+}
+
+func WithProcessors(ps ...spider.ItemProcessor) Option {
+	return func(opts *options) {
+		opts.Processors = append(opts.Processors, ps...)
+	}
+}
+
+// WithResultWorkers 设置保存结果的并发数和每个协程的缓冲大小.
+func WithResultWorkers(workers, buffer int) Option {
+	return func(opts *options) {
+		opts.ResultWorkers = workers
+		opts.ResultBuffer = buffer
+	}
+}
+
+func WithShutdownTimeout(d time.Duration) Option {
+	return func(opts *options) {
+		opts.ShutdownTimeout = d
+	}
+}
+
+func WithStrictSchema(strict bool) Option {
+	return func(opts *options) {
+		opts.StrictSchema = strict
+	}
+}
+
 func WithScheduler(scheduler Scheduler) Option {
 	return func(opts *options) {
 		opts.scheduler = scheduler
 	}
 }
 
+func WithRetryPolicy(policy *spider.RetryPolicy) Option {
+	return func(opts *options) {
+		opts.RetryPolicy = policy
+	}
+}
+
+func WithValidator(validator spider.Validator) Option {
+	return func(opts *options) {
+		opts.Validator = validator
+	}
+}
+
 func WithFetcher(fetcher spider.Fetcher) Option {
 	return func(opts *options) {
 		opts.Fetcher = fetcher
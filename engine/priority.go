@@ -0,0 +1,40 @@
+package engine
+
+import (
+	"time"
+
+	"github.com/Ysoding/pokemon-wiki-spider/spider"
+)
+
+// PrioritySchedule 按 spider.Request.Priority 调度, 值越大越先被抓取.
+// 排队越久的请求优先级越高, 避免低优先级请求饿死.
+type PrioritySchedule struct {
+	s *Schedule
+}
+
+// NewPrioritySchedule aging 为请求有效优先级加 1 所需的排队时长, <= 0 时不老化.
+func NewPrioritySchedule(aging time.Duration) *PrioritySchedule {
+	return &PrioritySchedule{
+		s: newSchedule(newPriorityQueue(aging)),
+	}
+}
+
+func (p *PrioritySchedule) Schedule() {
+	p.s.Schedule()
+}
+
+func (p *PrioritySchedule) Push(requests ...*spider.Request) error {
+	return p.s.Push(requests...)
+}
+
+func (p *PrioritySchedule) Pull() *spider.Request {
+	return p.s.Pull()
+}
+
+func (p *PrioritySchedule) Close() {
+	p.s.Close()
+}
+
+func (p *PrioritySchedule) Drop(match func(*spider.Request) bool) []*spider.Request {
+	return p.s.Drop(match)
+}
@@ -0,0 +1,158 @@
+package engine
+
+import (
+	"container/heap"
+	"time"
+
+	"github.com/Ysoding/pokemon-wiki-spider/global"
+	"github.com/Ysoding/pokemon-wiki-spider/spider"
+)
+
+type requestQueue interface {
+	Push(req *spider.Request)
+	Peek() *spider.Request
+	Pop() *spider.Request
+	Len() int
+	// Remove 删除并返回满足 match 的请求
+	Remove(match func(*spider.Request) bool) []*spider.Request
+}
+
+type fifoQueue struct {
+	reqs []*spider.Request
+}
+
+func (q *fifoQueue) Push(req *spider.Request) {
+	q.reqs = append(q.reqs, req)
+}
+
+func (q *fifoQueue) Peek() *spider.Request {
+	return q.reqs[0]
+}
+
+func (q *fifoQueue) Pop() *spider.Request {
+	req := q.reqs[0]
+	q.reqs[0] = nil
+	q.reqs = q.reqs[1:]
+	return req
+}
+
+func (q *fifoQueue) Len() int {
+	return len(q.reqs)
+}
+
+func (q *fifoQueue) Remove(match func(*spider.Request) bool) []*spider.Request {
+	var removed []*spider.Request
+	kept := q.reqs[:0]
+	for _, req := range q.reqs {
+		if match(req) {
+			removed = append(removed, req)
+		} else {
+			kept = append(kept, req)
+		}
+	}
+	for i := len(kept); i < len(q.reqs); i++ {
+		q.reqs[i] = nil
+	}
+	q.reqs = kept
+	return removed
+}
+
+type priorityItem struct {
+	req   *spider.Request
+	score float64
+	seq   uint64
+}
+
+// priorityQueue 按 Priority 从高到低出队, 同优先级先进先出.
+//
+// 老化: 请求每排队 aging 时长, 有效优先级加 1. 比较两个请求时
+//
+//	Pa + (now-ta)/aging > Pb + (now-tb)/aging  <=>  Pa - ta/aging > Pb - tb/aging
+//
+// now 被消掉了, 所以入队时算好 score 即可, 堆不需要随时间重排.
+type priorityQueue struct {
+	items []*priorityItem
+	aging time.Duration
+	seq   uint64
+	now   func() time.Time
+}
+
+func newPriorityQueue(aging time.Duration) *priorityQueue {
+	return &priorityQueue{
+		aging: aging,
+		now:   time.Now,
+	}
+}
+
+// effectivePriority 重试的请求插队, 只加一次, 不随重试次数累加.
+func effectivePriority(req *spider.Request) int {
+	if req.Attempt > 0 {
+		return req.Priority + global.DefaultRetryPriority
+	}
+	return req.Priority
+}
+
+func (q *priorityQueue) Push(req *spider.Request) {
+	score := float64(effectivePriority(req))
+	if q.aging > 0 {
+		score -= float64(q.now().UnixNano()) / float64(q.aging)
+	}
+	q.seq++
+	heap.Push((*priorityHeap)(q), &priorityItem{req: req, score: score, seq: q.seq})
+}
+
+func (q *priorityQueue) Peek() *spider.Request {
+	return q.items[0].req
+}
+
+func (q *priorityQueue) Pop() *spider.Request {
+	return heap.Pop((*priorityHeap)(q)).(*priorityItem).req
+}
+
+func (q *priorityQueue) Len() int {
+	return len(q.items)
+}
+
+func (q *priorityQueue) Remove(match func(*spider.Request) bool) []*spider.Request {
+	var removed []*spider.Request
+	kept := q.items[:0]
+	for _, item := range q.items {
+		if match(item.req) {
+			removed = append(removed, item.req)
+		} else {
+			kept = append(kept, item)
+		}
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+	for i := len(kept); i < len(q.items); i++ {
+		q.items[i] = nil
+	}
+	q.items = kept
+	heap.Init((*priorityHeap)(q))
+	return removed
+}
+
+type priorityHeap priorityQueue
+
+func (h *priorityHeap) Len() int { return len(h.items) }
+
+func (h *priorityHeap) Less(i, j int) bool {
+	if h.items[i].score != h.items[j].score {
+		return h.items[i].score > h.items[j].score
+	}
+	return h.items[i].seq < h.items[j].seq
+}
+
+func (h *priorityHeap) Swap(i, j int) { h.items[i], h.items[j] = h.items[j], h.items[i] }
+
+func (h *priorityHeap) Push(x interface{}) { h.items = append(h.items, x.(*priorityItem)) }
+
+func (h *priorityHeap) Pop() interface{} {
+	n := len(h.items)
+	item := h.items[n-1]
+	h.items[n-1] = nil
+	h.items = h.items[:n-1]
+	return item
+}
@@ -0,0 +1,171 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Ysoding/pokemon-wiki-spider/spider"
+)
+
+func drain(q requestQueue) []string {
+	var urls []string
+	for q.Len() > 0 {
+		peek := q.Peek()
+		req := q.Pop()
+		if peek != req {
+			panic("Peek and Pop disagree")
+		}
+		urls = append(urls, req.URL)
+	}
+	return urls
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestFifoQueue(t *testing.T) {
+	q := &fifoQueue{}
+	for _, u := range []string{"a", "b", "c"} {
+		q.Push(&spider.Request{URL: u})
+	}
+	if got := drain(q); !equal(got, []string{"a", "b", "c"}) {
+		t.Fatalf("got %v", got)
+	}
+}
+
+func TestPriorityQueueOrder(t *testing.T) {
+	q := newPriorityQueue(0)
+	q.Push(&spider.Request{URL: "detail1"})
+	q.Push(&spider.Request{URL: "list", Priority: 10})
+	q.Push(&spider.Request{URL: "detail2"})
+	q.Push(&spider.Request{URL: "index", Priority: 10})
+	q.Push(&spider.Request{URL: "low", Priority: -1})
+
+	want := []string{"list", "index", "detail1", "detail2", "low"}
+	if got := drain(q); !equal(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestPriorityQueueRetryBoostOnce(t *testing.T) {
+	q := newPriorityQueue(0)
+	// 重试多少次都只比同优先级的新请求高一级, 不会超过列表页
+	q.Push(&spider.Request{URL: "new"})
+	q.Push(&spider.Request{URL: "retry", Attempt: 5})
+	q.Push(&spider.Request{URL: "list", Priority: 10})
+
+	want := []string{"list", "retry", "new"}
+	if got := drain(q); !equal(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestPriorityQueueAging(t *testing.T) {
+	now := time.Unix(0, 0)
+	q := newPriorityQueue(time.Second)
+	q.now = func() time.Time { return now }
+
+	q.Push(&spider.Request{URL: "old"})
+	now = now.Add(3 * time.Second)
+	q.Push(&spider.Request{URL: "high", Priority: 2})
+	q.Push(&spider.Request{URL: "higher", Priority: 4})
+
+	// old 排队 3 秒, 有效优先级为 3
+	want := []string{"higher", "old", "high"}
+	if got := drain(q); !equal(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestFairQueueWeights(t *testing.T) {
+	a := &spider.Task{Options: spider.Options{Name: "a", Weight: 2}}
+	b := &spider.Task{Options: spider.Options{Name: "b"}}
+
+	q := newFairQueue(0)
+	for _, u := range []string{"a1", "a2", "a3", "a4"} {
+		q.Push(&spider.Request{URL: u, Task: a})
+	}
+	for _, u := range []string{"b1", "b2"} {
+		q.Push(&spider.Request{URL: u, Task: b})
+	}
+
+	want := []string{"a1", "b1", "a2", "a3", "b2", "a4"}
+	if got := drain(q); !equal(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestFairQueuePriorityWithinTask(t *testing.T) {
+	a := &spider.Task{Options: spider.Options{Name: "a"}}
+
+	q := newFairQueue(0)
+	q.Push(&spider.Request{URL: "detail", Task: a})
+	q.Push(&spider.Request{URL: "list", Task: a, Priority: 10})
+
+	want := []string{"list", "detail"}
+	if got := drain(q); !equal(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+// 变空的子队列不保留之前的份额, 重新有请求时按权重正常轮询
+func TestFairQueueRefill(t *testing.T) {
+	a := &spider.Task{Options: spider.Options{Name: "a"}}
+	b := &spider.Task{Options: spider.Options{Name: "b", Weight: 3}}
+
+	q := newFairQueue(0)
+	q.Push(&spider.Request{URL: "a1", Task: a})
+	for i := 0; i < 6; i++ {
+		q.Push(&spider.Request{URL: "b", Task: b})
+	}
+
+	want := []string{"b", "a1", "b"}
+	var got []string
+	for range want {
+		got = append(got, q.Pop().URL)
+	}
+	if !equal(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	q.Push(&spider.Request{URL: "a2", Task: a})
+	q.Push(&spider.Request{URL: "a3", Task: a})
+	want = []string{"b", "b", "a2"}
+	got = nil
+	for range want {
+		got = append(got, q.Pop().URL)
+	}
+	if !equal(got, want) {
+		t.Fatalf("after refill got %v, want %v", got, want)
+	}
+}
+
+func TestFairQueueRemoveResets(t *testing.T) {
+	a := &spider.Task{Options: spider.Options{Name: "a"}}
+	b := &spider.Task{Options: spider.Options{Name: "b"}}
+
+	q := newFairQueue(0)
+	q.Push(&spider.Request{URL: "a1", Task: a})
+	q.Push(&spider.Request{URL: "b1", Task: b})
+	q.Push(&spider.Request{URL: "b2", Task: b})
+	q.Pop()
+
+	removed := q.Remove(func(req *spider.Request) bool { return req.Task == b })
+	if len(removed) != 2 || q.Len() != 0 {
+		t.Fatalf("removed %d, len %d", len(removed), q.Len())
+	}
+	for _, tq := range q.order {
+		if tq.current != 0 {
+			t.Fatalf("empty queue keeps current %d", tq.current)
+		}
+	}
+}
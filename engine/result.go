@@ -0,0 +1,69 @@
+package engine
+
+import (
+	"hash/fnv"
+	"sync"
+
+	"github.com/Ysoding/pokemon-wiki-spider/spider"
+)
+
+// resultPipeline 把解析结果按任务分片交给多个消费者保存, 同一个任务的结果由同一个消费者按顺序处理.
+// 每个分片有固定大小的缓冲, 存储跟不上时 worker 阻塞在 send 上.
+type resultPipeline struct {
+	shards []chan output
+	wg     sync.WaitGroup
+}
+
+func newResultPipeline(workers, buffer int) *resultPipeline {
+	if workers <= 0 {
+		workers = 1
+	}
+	if buffer < 0 {
+		buffer = 0
+	}
+	p := &resultPipeline{shards: make([]chan output, workers)}
+	for i := range p.shards {
+		p.shards[i] = make(chan output, buffer)
+	}
+	return p
+}
+
+func (p *resultPipeline) shard(task *spider.Task) chan output {
+	if len(p.shards) == 1 {
+		return p.shards[0]
+	}
+	h := fnv.New32a()
+	h.Write([]byte(task.Name))
+	return p.shards[h.Sum32()%uint32(len(p.shards))]
+}
+
+func (p *resultPipeline) send(out output) {
+	p.shard(out.req.Task) <- out
+}
+
+func (p *resultPipeline) start(handle func(<-chan output)) {
+	for _, ch := range p.shards {
+		p.wg.Add(1)
+		go func(ch chan output) {
+			defer p.wg.Done()
+			handle(ch)
+		}(ch)
+	}
+}
+
+// close 关闭所有分片并等待剩下的结果处理完, 调用前 worker 必须已经退出.
+func (p *resultPipeline) close() {
+	for _, ch := range p.shards {
+		close(ch)
+	}
+	p.wg.Wait()
+}
+
+// depth 每个分片中等待保存的结果数.
+func (p *resultPipeline) depth() []int {
+	depth := make([]int, len(p.shards))
+	for i, ch := range p.shards {
+		depth[i] = len(ch)
+	}
+	return depth
+}
@@ -2,28 +2,78 @@ package engine
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"runtime/debug"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/Ysoding/pokemon-wiki-spider/deadletter"
+	"github.com/Ysoding/pokemon-wiki-spider/dedup"
 	"github.com/Ysoding/pokemon-wiki-spider/spider"
 	"go.uber.org/zap"
 )
 
+// Scheduler Close 之后 Push 返回 ErrSchedulerClosed, Pull 返回 nil.
 type Scheduler interface {
 	Schedule()
-	Push(...*spider.Request)
+	Push(...*spider.Request) error
 	Pull() *spider.Request
 	Close()
 }
 
+var ErrSchedulerClosed = errors.New("scheduler closed")
+
+// Dropper 可以删除排队中请求的 Scheduler, 任务预算用完时用来丢弃该任务剩下的请求.
+// 没有实现时这些请求在出队后才丢弃.
+type Dropper interface {
+	Drop(match func(*spider.Request) bool) []*spider.Request
+}
+
+type output struct {
+	req    *spider.Request
+	result spider.ParseResult
+}
+
 type Crawler struct {
-	out          chan spider.ParseResult
-	visisted     map[string]bool
-	visistedLock sync.Mutex
+	results *resultPipeline
+	// 启动时直接入队的请求, 例如重放或者断点中的请求, 不做去重
+	direct map[*spider.Request]bool
+	// 本次运行中已经开始抓取但还没保存的请求, 防止重复抓取; 数据交给 Storage 后才写入 Dedup
+	visiting  map[dedup.Store]map[string]bool
+	visitLock sync.Mutex
+	// 保存数据, 写入访问记录和 finish 持有读锁, 保存断点时持有写锁,
+	// 这样断点中记为访问过的请求, 数据一定已经 flush 过
+	checkpointLock sync.RWMutex
 
 	failures     map[string]*spider.Request // id -> request
 	failuresLock sync.Mutex
 	wg           *sync.WaitGroup
+
+	// 已入队但还没处理完的请求, 重试时同一个请求会在完成前再次入队, 所以记录次数
+	pending     map[*spider.Request]int
+	pendingCnt  int
+	pendingLock sync.Mutex
+	tasks       map[string]*taskState // 同样由 pendingLock 保护
+	taskOrder   []*taskState
+	ctx         context.Context
+
+	done     chan struct{}
+	doneOnce sync.Once
+	quit     chan struct{}
+	quitOnce sync.Once
+	stopped  chan struct{}
+	stopErr  error
+	state    int32
+	stats    stats
+	hosts    *hostLimiter
+
+	inFlight  int64
+	pauseLock sync.Mutex
+	paused    chan struct{} // 暂停时不为 nil, Resume 时关闭
+	pausedAt  time.Time
+	pauseTime time.Duration // 之前各次暂停的总时长, 不包括正在进行的暂停
 	options
 }
 
@@ -34,115 +84,370 @@ func NewEngine(opts ...Option) *Crawler {
 		opt(&options)
 	}
 
+	if options.Dedup == nil {
+		options.Dedup = dedup.NewMemory()
+	}
+	// 默认策略是共享的模板, engine 持有自己的副本
+	options.RetryPolicy = options.RetryPolicy.Clone()
+
 	c := &Crawler{
-		out:      make(chan spider.ParseResult),
-		visisted: make(map[string]bool),
+		results:  newResultPipeline(options.ResultWorkers, options.ResultBuffer),
+		visiting: make(map[dedup.Store]map[string]bool),
 		failures: make(map[string]*spider.Request),
+		pending:  make(map[*spider.Request]int),
 		options:  options,
 		wg:       &sync.WaitGroup{},
+		done:     make(chan struct{}),
+		quit:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+		hosts:    newHostLimiter(options.MaxPerHost, options.HostLimits),
 	}
 
 	return c
 }
 
-func (c *Crawler) handleSeeds() {
-	res := []*spider.Request{}
-	for _, task := range c.Seeds {
-		c.Logger.Info("parse task", zap.String("Name", task.Name))
-
-		task.Fetcher = c.Fetcher
-
-		reqs, err := task.Rule.Root()
-		if err != nil {
-			c.Logger.Error("got task root failed", zap.Error(err))
-			continue
-		}
+// Run 阻塞直到所有请求处理完毕, ctx 被取消或者调用了 Shutdown, 返回本次抓取的统计.
+func (c *Crawler) Run(ctx context.Context) (Summary, error) {
+	c.stats.begin()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	c.ctx = ctx
+	c.setState(StateRunning)
+
+	// Status 可能同时在读任务状态
+	c.pendingLock.Lock()
+	err := c.initTasks()
+	c.pendingLock.Unlock()
+	if err != nil {
+		c.setState(StateStopped)
+		close(c.stopped)
+		return Summary{}, err
+	}
 
-		for _, req := range reqs {
-			c.Logger.Info("request", zap.String("URL", req.URL))
-			req.Task = task
+	c.direct = make(map[*spider.Request]bool)
+	for _, st := range c.taskOrder {
+		for _, req := range st.requests {
+			c.direct[req] = true
 		}
-
-		res = append(res, reqs...)
 	}
-	go c.scheduler.Push(res...)
-	c.Logger.Info("parse task done")
-}
 
-func (c *Crawler) Run() error {
 	go c.schedule()
 
 	for i := 0; i < c.WorkerCount; i++ {
 		c.wg.Add(1)
-		go c.createWorker(c.wg)
+		go c.createWorker(ctx, c.wg)
 	}
 
-	go c.handleSeeds()
-	go c.handleResult()
-	c.wg.Wait()
-	return nil
+	c.results.start(c.handleResult)
+	c.pendingLock.Lock()
+	c.startReadyTasks()
+	c.pendingLock.Unlock()
+
+	if c.Checkpoint != nil {
+		go c.runCheckpoint()
+	}
+
+	select {
+	case <-c.done:
+		c.Logger.Info("crawler finished, all requests done")
+	case <-c.quit:
+	case <-ctx.Done():
+	}
+
+	// 打断正在进行的限流等待, 随机等待和 HTTP 请求
+	cancel()
+	err = c.stop()
+	summary := c.stats.summary()
+	c.Logger.Info("crawler summary",
+		zap.Int64("requests", summary.Requests),
+		zap.Int64("succeeded", summary.Succeeded),
+		zap.Int64("failed", summary.Failed),
+		zap.Int64("items", summary.Items),
+		zap.Int64("dropped", summary.Dropped),
+		zap.Int64("violations", summary.Violations),
+		zap.Duration("duration", summary.Duration),
+	)
+	return summary, err
 }
 
-func (c *Crawler) Shutdown() {
-	c.scheduler.Close()
-	c.wg.Wait()
-	if c.Storage != nil {
-		err := c.Storage.Flush()
-		if err != nil {
+// Shutdown 通知 Run 停止并等待收尾完成, 收尾超过 ShutdownTimeout 时返回 ErrShutdownTimeout.
+func (c *Crawler) Shutdown() error {
+	c.quitOnce.Do(func() {
+		close(c.quit)
+	})
+	<-c.stopped
+	return c.stopErr
+}
+
+// stop 停止调度, 等待 worker 退出, 把已经解析的结果保存完并 flush storage, 最后保存断点.
+// 超过 ShutdownTimeout 时不再等待, 也不保存断点, 下次从上一次保存的断点继续.
+func (c *Crawler) stop() error {
+	defer close(c.stopped)
+	c.setState(StateDraining)
+
+	drained := make(chan struct{})
+	go func() {
+		defer close(drained)
+		c.scheduler.Close()
+		c.wg.Wait()
+		c.results.close()
+		c.flushStorage()
+	}()
+
+	if c.ShutdownTimeout > 0 {
+		timer := time.NewTimer(c.ShutdownTimeout)
+		defer timer.Stop()
+		select {
+		case <-drained:
+		case <-timer.C:
+			c.stopErr = ErrShutdownTimeout
+			c.Logger.Error("crawler shutdown timeout", zap.Duration("timeout", c.ShutdownTimeout))
+		}
+	} else {
+		<-drained
+	}
+
+	// 超时后 storage 可能卡住, 没 flush 的数据不能记为访问过
+	if c.Checkpoint != nil && c.stopErr == nil {
+		c.saveCheckpoint()
+	}
+	c.setState(StateStopped)
+	return c.stopErr
+}
+
+// flushStorage flush engine 和任务各自的 storage.
+func (c *Crawler) flushStorage() {
+	storages := []spider.Storage{c.Storage}
+	c.pendingLock.Lock()
+	for _, st := range c.taskOrder {
+		storages = append(storages, st.task.Storage)
+	}
+	c.pendingLock.Unlock()
+
+	flushed := make(map[spider.Storage]bool)
+	for _, s := range storages {
+		if s == nil || flushed[s] {
+			continue
+		}
+		flushed[s] = true
+		if err := s.Flush(); err != nil {
 			c.Logger.Error("crawler storage flush", zap.Error(err))
 		}
 	}
 }
 
-func (c *Crawler) handleResult() {
-	for result := range c.out {
-		for _, item := range result.Items {
+func (c *Crawler) push(reqs ...*spider.Request) {
+	reqs = c.dropExhausted(reqs)
+	if len(reqs) == 0 {
+		return
+	}
+	c.addPending(reqs...)
+	go c.schedulePush(reqs...)
+}
+
+// schedulePush 调度器关闭后推不进去的请求留在 pending 里, 会写进断点.
+func (c *Crawler) schedulePush(reqs ...*spider.Request) {
+	if err := c.scheduler.Push(reqs...); err != nil {
+		c.Logger.Debug("push request failed", zap.Int("count", len(reqs)), zap.Error(err))
+	}
+}
+
+func (c *Crawler) pushAfter(d time.Duration, reqs ...*spider.Request) {
+	if d <= 0 {
+		c.push(reqs...)
+		return
+	}
+	reqs = c.dropExhausted(reqs)
+	if len(reqs) == 0 {
+		return
+	}
+	c.addPending(reqs...)
+	time.AfterFunc(d, func() {
+		if c.ctx.Err() != nil {
+			return
+		}
+		c.schedulePush(reqs...)
+	})
+}
+
+func (c *Crawler) addPending(reqs ...*spider.Request) {
+	c.pendingLock.Lock()
+	defer c.pendingLock.Unlock()
+	for _, req := range reqs {
+		c.pending[req]++
+		if st, ok := c.tasks[req.Task.Name]; ok {
+			st.pending++
+		}
+	}
+	c.pendingCnt += len(reqs)
+}
+
+// finish 标记一个请求处理完毕, 子请求和重试必须在此之前 push.
+// 抓取成功的请求要等数据交给 Storage 之后才算完成.
+func (c *Crawler) finish(req *spider.Request) {
+	c.pendingLock.Lock()
+	defer c.pendingLock.Unlock()
+	if c.pending[req]--; c.pending[req] <= 0 {
+		delete(c.pending, req)
+	}
+	c.pendingCnt--
+	if st, ok := c.tasks[req.Task.Name]; ok {
+		st.pending--
+		c.checkTask(st)
+	}
+	c.checkDone()
+}
+
+func (c *Crawler) markDone() {
+	c.doneOnce.Do(func() {
+		close(c.done)
+	})
+}
+
+func (c *Crawler) handleResult(results <-chan output) {
+	for out := range results {
+		c.checkpointLock.RLock()
+		for _, item := range out.result.Items {
 			switch d := item.(type) {
 			case *spider.DataCell:
-				c.Logger.Sugar().Info("crawler", "got item", d)
-				if c.Storage != nil {
-					s := c.Storage
-					if d.Task.Storage != nil {
-						s = d.Task.Storage
-					}
-					if err := s.Save(d); err != nil {
-						c.Logger.Error("storage save err:", zap.Error(err))
-					}
+				for _, d := range c.processItem(d) {
+					c.saveItem(d)
 				}
 			}
 		}
+		// 数据交给 Storage 之后才记为访问过
+		if err := c.storeVisited(out.req); err != nil {
+			c.Logger.Error("dedup store add failed", zap.String("url", out.req.URL), zap.Error(err))
+		}
+		c.finish(out.req)
+		c.checkpointLock.RUnlock()
 	}
 }
 
-func (c *Crawler) hashVisited(req *spider.Request) bool {
-	c.visistedLock.Lock()
-	defer c.visistedLock.Unlock()
-	return c.visisted[req.Unique()]
+func (c *Crawler) saveItem(d *spider.DataCell) {
+	if !c.validateItem(d) && c.StrictSchema {
+		return
+	}
+	if !c.takeItem(d.Task) {
+		return
+	}
+	atomic.AddInt64(&c.stats.items, 1)
+	c.Logger.Sugar().Info("crawler", "got item", d)
+	if c.Storage != nil {
+		s := c.Storage
+		if d.Task.Storage != nil {
+			s = d.Task.Storage
+		}
+		if err := s.Save(d); err != nil {
+			c.Logger.Error("storage save err:", zap.Error(err))
+		}
+	}
 }
 
-func (c *Crawler) storeVisited(reqs ...*spider.Request) {
-	c.visistedLock.Lock()
-	defer c.visistedLock.Unlock()
-	for _, r := range reqs {
-		c.visisted[r.Unique()] = true
+// dedupStore 任务自己的 Dedup 优先, 否则使用 engine 的.
+func (c *Crawler) dedupStore(task *spider.Task) dedup.Store {
+	if task.Dedup != nil {
+		return task.Dedup
 	}
+	return c.Dedup
 }
 
-func (c *Crawler) setFailure(req *spider.Request) {
-	c.failuresLock.Lock()
-	defer c.failuresLock.Unlock()
-	if _, ok := c.failures[req.Unique()]; !ok {
-		c.failures[req.Unique()] = req
-		c.scheduler.Push(req)
+// claimVisit 请求已经访问过或者本次运行中正在抓取时返回 false.
+func (c *Crawler) claimVisit(req *spider.Request) (bool, error) {
+	store, key := c.dedupStore(req.Task), req.Unique()
+
+	c.visitLock.Lock()
+	defer c.visitLock.Unlock()
+
+	seen := c.visiting[store]
+	if seen[key] {
+		return false, nil
+	}
+	visited, err := store.Has(key)
+	if err != nil || visited {
+		return false, err
+	}
+
+	if seen == nil {
+		seen = make(map[string]bool)
+		c.visiting[store] = seen
 	}
+	seen[key] = true
+	return true, nil
 }
 
-func (c *Crawler) createWorker(wg *sync.WaitGroup) {
-	defer func() {
-		if err := recover(); err != nil {
-			c.Logger.Sugar().Errorw("worker", "err", err, "stack", string(debug.Stack()))
+// storeVisited 请求的数据交给 Storage 后才写入 Dedup, 永久失败或者被取消的请求下次运行还会抓取.
+func (c *Crawler) storeVisited(req *spider.Request) error {
+	store, key := c.dedupStore(req.Task), req.Unique()
+
+	c.visitLock.Lock()
+	defer c.visitLock.Unlock()
+
+	if err := store.Add(key); err != nil {
+		return err
+	}
+	delete(c.visiting[store], key)
+	return nil
+}
+
+func (c *Crawler) retryPolicy(task *spider.Task) *spider.RetryPolicy {
+	c.pendingLock.Lock()
+	defer c.pendingLock.Unlock()
+	if st, ok := c.tasks[task.Name]; ok {
+		return st.retryPolicy
+	}
+	if task.RetryPolicy != nil {
+		return task.RetryPolicy
+	}
+	return c.RetryPolicy
+}
+
+func (c *Crawler) validator(task *spider.Task, rule *spider.Rule) spider.Validator {
+	if rule.Validator != nil {
+		return rule.Validator
+	}
+	if task.Validator != nil {
+		return task.Validator
+	}
+	return c.Validator
+}
+
+func (c *Crawler) setFailure(req *spider.Request, err error) {
+	c.onError(req, err)
+
+	policy := c.retryPolicy(req.Task)
+	if policy != nil && policy.ShouldRetry(err, req.Attempt) {
+		delay := policy.Backoff(req.Attempt)
+		c.Logger.Info("retry request",
+			zap.String("url", req.URL),
+			zap.Int("attempt", req.Attempt),
+			zap.Duration("delay", delay),
+		)
+		// 重试的请求由队列插队, 见 effectivePriority
+		c.pushAfter(delay, req)
+		return
+	}
+
+	c.failuresLock.Lock()
+	c.failures[req.Unique()] = req
+	c.failuresLock.Unlock()
+
+	atomic.AddInt64(&c.stats.failed, 1)
+	c.Logger.Error("request failed",
+		zap.String("url", req.URL),
+		zap.Int("attempt", req.Attempt),
+		zap.Error(err),
+	)
+
+	if c.DeadLetter != nil {
+		if err := c.DeadLetter.Put(deadletter.NewEntry(req, err)); err != nil {
+			c.Logger.Error("dead letter put failed", zap.String("url", req.URL), zap.Error(err))
 		}
-	}()
+	}
+}
+
+func (c *Crawler) createWorker(ctx context.Context, wg *sync.WaitGroup) {
 	defer wg.Done()
 
 	for {
@@ -151,124 +456,276 @@ func (c *Crawler) createWorker(wg *sync.WaitGroup) {
 			return
 		}
 
-		c.Logger.Info("start parse req", zap.String("URL", req.URL))
-		if err := req.Check(); err != nil {
-			c.Logger.Debug("request check failed", zap.Error(err))
-		}
+		c.handleRequest(ctx, req)
+	}
+}
 
-		if c.hashVisited(req) {
-			c.Logger.Debug("requst has visisted ", zap.String("url", req.URL))
-			continue
+func (c *Crawler) handleRequest(ctx context.Context, req *spider.Request) {
+	// 被取消的请求不算处理完, 留在 pending 里写进断点;
+	// 解析成功的请求交给 handleResult 保存后再 finish
+	keep := false
+	defer func() {
+		if !keep {
+			c.finish(req)
+		}
+	}()
+	// 单个请求 panic 时记为永久失败, worker 继续处理下一个请求
+	defer func() {
+		if r := recover(); r != nil {
+			c.Logger.Sugar().Errorw("worker", "err", r, "stack", string(debug.Stack()))
+			c.setFailure(req, &spider.PermanentError{Err: fmt.Errorf("panic: %v", r)})
 		}
+	}()
 
-		c.storeVisited(req)
+	if err := c.waitResume(ctx); err != nil {
+		keep = true
+		return
+	}
+	atomic.AddInt64(&c.inFlight, 1)
+	defer atomic.AddInt64(&c.inFlight, -1)
+
+	c.Logger.Info("start parse req", zap.String("URL", req.URL))
+	if err := req.Check(); err != nil {
+		atomic.AddInt64(&c.stats.dropped, 1)
+		c.Logger.Info("drop request", zap.String("url", req.URL), zap.Int64("depth", req.Depth), zap.Error(err))
+		return
+	}
 
-		if req.Task.Limit != nil {
-			c.Logger.Info("limiter", zap.Any("", req.Task.Limit))
-			if err := req.Task.Limit.Wait(context.TODO()); err != nil {
-				c.Logger.Error("limiter wait error ",
-					zap.Error(err),
-				)
-				continue
-			}
-		}
+	// 预算用完的请求不标记访问, 增量抓取时下次还会抓
+	if !c.takeRequest(req.Task) {
+		atomic.AddInt64(&c.stats.dropped, 1)
+		c.Logger.Debug("drop request, task budget exhausted", zap.String("url", req.URL))
+		return
+	}
 
-		c.Logger.Info("start fetch body", zap.String("URL", req.URL))
-		body, err := req.Fetch()
+	// 重试的请求已经占用过
+	if req.Attempt == 0 && !c.direct[req] {
+		claimed, err := c.claimVisit(req)
 		if err != nil {
-			c.Logger.Error("can't fetch ",
-				zap.Error(err),
-				zap.String("url", req.URL),
-			)
-			c.setFailure(req)
-			continue
+			c.returnRequest(req.Task)
+			c.Logger.Error("dedup store has failed", zap.String("url", req.URL), zap.Error(err))
+			return
+		}
+		if !claimed {
+			c.returnRequest(req.Task)
+			c.Logger.Debug("requst has visisted ", zap.String("url", req.URL))
+			return
+		}
+	}
+
+	resp, err := c.onRequest(ctx, req)
+	if err != nil {
+		if ctx.Err() != nil {
+			keep = true
+			return
 		}
+		atomic.AddInt64(&c.stats.dropped, 1)
+		c.Logger.Info("drop request by middleware", zap.String("url", req.URL), zap.Error(err))
+		return
+	}
 
-		if len(body) < 6000 {
-			c.Logger.Error("can't fetch not correct length ",
-				zap.Int("length", len(body)),
-				zap.String("url", req.URL))
-			c.setFailure(req)
-			continue
+	if resp != nil {
+		req.Attempt++
+	} else {
+		resp, err = c.fetch(ctx, req)
+	}
+	if err == nil {
+		if resp.FetchedAt.IsZero() {
+			resp.FetchedAt = time.Now()
 		}
+		err = c.onResponse(req, resp)
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			keep = true
+			c.Logger.Info("fetch canceled", zap.String("url", req.URL))
+			return
+		}
+		c.Logger.Error("can't fetch ",
+			zap.Error(err),
+			zap.String("url", req.URL),
+		)
+		c.setFailure(req, err)
+		return
+	}
 
-		c.Logger.Info("start call parse func", zap.String("URL", req.URL))
-		rule := req.Task.Rule.Trunk[req.RuleName]
-		result, err := rule.ParseFunc(&spider.Context{
-			Body: body,
-			Req:  req,
-		})
+	rule := req.Task.Rule.Trunk[req.RuleName]
+	verdict, err := c.validator(req.Task, rule)(resp)
+	if verdict != spider.Accept && err == nil {
+		err = fmt.Errorf("invalid response status code:%d", resp.StatusCode)
+	}
+	switch verdict {
+	case spider.Retry:
+		c.Logger.Error("invalid response, retry",
+			zap.Int("status", resp.StatusCode),
+			zap.Int("length", len(resp.Body)),
+			zap.String("url", req.URL),
+			zap.Error(err))
+		// Validator 已经判定可以重试, 不受 RetryableStatus 限制
+		c.setFailure(req, &spider.RetryableError{Err: err})
+		return
+	case spider.Reject:
+		c.Logger.Error("invalid response, reject",
+			zap.Int("status", resp.StatusCode),
+			zap.Int("length", len(resp.Body)),
+			zap.String("url", req.URL),
+			zap.Error(err))
+		c.setFailure(req, &spider.PermanentError{Err: err})
+		return
+	}
 
-		if err != nil {
-			c.Logger.Error("ParseFunc failed ", zap.String("url", req.URL), zap.Error(err))
-			continue
+	c.Logger.Info("start call parse func", zap.String("URL", req.URL))
+	result, err := rule.ParseFunc(&spider.Context{
+		Body: resp.Body,
+		Resp: resp,
+		Req:  req,
+	})
+
+	if err != nil {
+		c.Logger.Error("ParseFunc failed ", zap.String("url", req.URL), zap.Error(err))
+		c.onParseError(req, err)
+		// 重新抓取也解析不了, 记入死信, 修好解析后可以重放
+		c.setFailure(req, &spider.PermanentError{Err: fmt.Errorf("parse: %w", err)})
+		return
+	}
+	result.Items = c.onItems(req, result.Items)
+
+	atomic.AddInt64(&c.stats.succeeded, 1)
+	for _, child := range result.Requesrts {
+		child.Depth = req.Depth + 1
+		if child.Task == nil {
+			child.Task = req.Task
 		}
+	}
+	c.push(result.Requesrts...)
+	keep = true
+	c.results.send(output{req: req, result: result})
 
-		if len(result.Requesrts) > 0 {
-			go c.scheduler.Push(result.Requesrts...)
+	c.Logger.Info("parse req done", zap.String("URL", req.URL))
+}
+
+// fetch 等待限流和随机等待后再占用 host 空位, 等待中的请求不占 host 的并发数.
+func (c *Crawler) fetch(ctx context.Context, req *spider.Request) (*spider.Response, error) {
+	req.Attempt++
+	if req.Task.Limit != nil {
+		c.Logger.Info("limiter", zap.Any("", req.Task.Limit))
+		if err := req.Task.Limit.Wait(ctx); err != nil {
+			return nil, fmt.Errorf("limiter wait: %w", err)
 		}
-		c.out <- result
+	}
+	if err := req.Wait(ctx); err != nil {
+		return nil, err
+	}
 
-		c.Logger.Info("parse req done", zap.String("URL", req.URL))
+	release, err := c.hosts.acquire(ctx, req.URL)
+	if err != nil {
+		return nil, err
 	}
+	defer release()
+
+	c.Logger.Info("start fetch body", zap.String("URL", req.URL))
+	atomic.AddInt64(&c.stats.requests, 1)
+	return req.Task.Fetcher.Get(ctx, req)
 }
 
 func (c *Crawler) schedule() {
 	c.scheduler.Schedule()
 }
 
+type dropRequest struct {
+	match   func(*spider.Request) bool
+	removed chan []*spider.Request
+}
+
 type Schedule struct {
 	requestCh chan *spider.Request
-	workerCh  chan *spider.Request
-	reqQueue  []*spider.Request
+	workerCh  chan *spider.Request // 只由 Schedule 发送和关闭
+	dropCh    chan dropRequest
+	quit      chan struct{}
+	closeOnce sync.Once
+	reqQueue  requestQueue
 }
 
 func NewSchedule() *Schedule {
+	return newSchedule(&fifoQueue{})
+}
+
+func newSchedule(q requestQueue) *Schedule {
 	s := &Schedule{
 		requestCh: make(chan *spider.Request),
 		workerCh:  make(chan *spider.Request),
-		reqQueue:  make([]*spider.Request, 0),
+		dropCh:    make(chan dropRequest),
+		quit:      make(chan struct{}),
+		reqQueue:  q,
 	}
 
 	return s
 }
 
+// Schedule 直到 Close 才返回, 返回前关闭 workerCh 让 Pull 返回 nil, 队列中剩下的请求丢弃.
 func (s *Schedule) Schedule() {
-	var ch chan *spider.Request
-	var req *spider.Request
+	defer close(s.workerCh)
+
 	for {
-		if req == nil && len(s.reqQueue) > 0 {
-			req = s.reqQueue[0]
-			s.reqQueue = s.reqQueue[1:]
+		var ch chan *spider.Request
+		var req *spider.Request
+		// 只预览队首, 真正发给 worker 后才出队, 这样新到的高优先级请求可以插队
+		if s.reqQueue.Len() > 0 {
+			req = s.reqQueue.Peek()
 			ch = s.workerCh
 		}
 
 		select {
 		case r := <-s.requestCh:
-			if r == nil {
-				return
-			}
-			// TODO: 这里可以做优先级队列
-			s.reqQueue = append(s.reqQueue, r)
+			s.reqQueue.Push(r)
 		case ch <- req:
-			req = nil
-			ch = nil
+			s.reqQueue.Pop()
+		case d := <-s.dropCh:
+			d.removed <- s.reqQueue.Remove(d.match)
+		case <-s.quit:
+			return
 		}
 	}
 }
 
 func (s *Schedule) Close() {
-	close(s.requestCh)
-	close(s.workerCh)
+	s.closeOnce.Do(func() {
+		close(s.quit)
+	})
 }
 
-func (s *Schedule) Push(requests ...*spider.Request) {
+func (s *Schedule) Push(requests ...*spider.Request) error {
 	for _, req := range requests {
-		s.requestCh <- req
+		select {
+		case <-s.quit:
+			return ErrSchedulerClosed
+		default:
+		}
+
+		select {
+		case s.requestCh <- req:
+		case <-s.quit:
+			return ErrSchedulerClosed
+		}
+	}
+	return nil
+}
+
+// Drop 删除队列中满足 match 的请求, 关闭后返回 nil.
+func (s *Schedule) Drop(match func(*spider.Request) bool) []*spider.Request {
+	d := dropRequest{match: match, removed: make(chan []*spider.Request, 1)}
+	select {
+	case s.dropCh <- d:
+		return <-d.removed
+	case <-s.quit:
+		return nil
 	}
 }
 
 func (s *Schedule) Pull() *spider.Request {
-	r := <-s.workerCh
+	r, ok := <-s.workerCh
+	if !ok {
+		return nil
+	}
 	return r
 }
@@ -0,0 +1,259 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/Ysoding/pokemon-wiki-spider/deadletter"
+	"github.com/Ysoding/pokemon-wiki-spider/dedup"
+	"github.com/Ysoding/pokemon-wiki-spider/spider"
+)
+
+func TestDedupStoresOnlySucceeded(t *testing.T) {
+	task := newListTask("list", "https://a/ok", "https://a/ok", "https://a/missing")
+	fetch := &countFetch{status: map[string]int{"https://a/missing": http.StatusNotFound}}
+	visited := dedup.NewMemory()
+
+	e := NewEngine(
+		WithScheduler(NewSchedule()),
+		WithFetcher(fetch),
+		WithDedup(visited),
+		WithSeeds([]*spider.Task{task}),
+	)
+	if _, err := e.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// 同一次运行中重复的请求只抓一次
+	if got := fetch.fetched(); len(got) != 2 {
+		t.Fatalf("fetched %v, want ok and missing once each", got)
+	}
+
+	ok := &spider.Request{URL: "https://a/ok", RuleName: "parse", Task: task}
+	if has, _ := visited.Has(ok.Unique()); !has {
+		t.Fatal("succeeded request not stored as visited")
+	}
+	missing := &spider.Request{URL: "https://a/missing", RuleName: "parse", Task: task}
+	if has, _ := visited.Has(missing.Unique()); has {
+		t.Fatal("failed request stored as visited")
+	}
+}
+
+func TestWorkerSurvivesPanic(t *testing.T) {
+	task := newListTask("list", "https://a/1", "https://a/panic", "https://a/2")
+	task.Rule.Trunk["parse"].ParseFunc = func(ctx *spider.Context) (spider.ParseResult, error) {
+		if ctx.Req.URL == "https://a/panic" {
+			panic("bad page")
+		}
+		return spider.ParseResult{}, nil
+	}
+	fetch := &countFetch{}
+
+	// 只有一个 worker, panic 后还要继续处理剩下的请求
+	e := NewEngine(
+		WithScheduler(NewSchedule()),
+		WithWorkerCount(1),
+		WithFetcher(fetch),
+		WithSeeds([]*spider.Task{task}),
+	)
+	summary, err := e.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := fetch.fetched(); len(got) != 3 {
+		t.Fatalf("fetched %v, want all requests", got)
+	}
+	if summary.Succeeded != 2 || summary.Failed != 1 {
+		t.Fatalf("summary = %+v, want 2 succeeded and 1 failed", summary)
+	}
+	panicked := &spider.Request{URL: "https://a/panic", RuleName: "parse", Task: task}
+	if _, ok := e.failures[panicked.Unique()]; !ok {
+		t.Fatal("panicked request not recorded as a failure")
+	}
+}
+
+// memDeadLetter 记录写入的死信.
+type memDeadLetter struct {
+	mu      sync.Mutex
+	entries []*deadletter.Entry
+}
+
+func (s *memDeadLetter) Put(e *deadletter.Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, e)
+	return nil
+}
+
+func (s *memDeadLetter) Close() error { return nil }
+
+func TestParseErrorGoesToDeadLetter(t *testing.T) {
+	task := newListTask("list", "https://a/1", "https://a/bad")
+	task.Rule.Trunk["parse"].ParseFunc = func(ctx *spider.Context) (spider.ParseResult, error) {
+		if ctx.Req.URL == "https://a/bad" {
+			return spider.ParseResult{}, errors.New("table not found")
+		}
+		return spider.ParseResult{}, nil
+	}
+	fetch := &countFetch{}
+	dead := &memDeadLetter{}
+	var parseErrors []string
+	task.Middlewares = []spider.Middleware{{
+		OnParseError: func(req *spider.Request, err error) {
+			parseErrors = append(parseErrors, req.URL)
+		},
+	}}
+
+	e := NewEngine(
+		WithScheduler(NewSchedule()),
+		WithWorkerCount(1),
+		WithFetcher(fetch),
+		WithDeadLetter(dead),
+		WithSeeds([]*spider.Task{task}),
+	)
+	summary, err := e.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 解析失败不重试
+	if got := fetch.fetched(); len(got) != 2 {
+		t.Fatalf("fetched %v, want each request once", got)
+	}
+	if summary.Failed != 1 {
+		t.Fatalf("failed = %d, want 1", summary.Failed)
+	}
+	if len(parseErrors) != 1 || parseErrors[0] != "https://a/bad" {
+		t.Fatalf("OnParseError called for %v", parseErrors)
+	}
+	if len(dead.entries) != 1 || dead.entries[0].URL != "https://a/bad" || dead.entries[0].Task != "list" {
+		t.Fatalf("dead letter = %+v", dead.entries)
+	}
+}
+
+func TestChildRequestsInheritDepthAndTask(t *testing.T) {
+	task := newListTask("chain", "https://a/0")
+	var (
+		mu     sync.Mutex
+		parsed = map[string]*spider.Request{}
+	)
+	// 每个页面都链接到下一页, 深度超过 MaxDepth 的请求被丢弃
+	task.MaxDepth = 2
+	task.Rule.Trunk["parse"].ParseFunc = func(ctx *spider.Context) (spider.ParseResult, error) {
+		mu.Lock()
+		parsed[ctx.Req.URL] = ctx.Req
+		mu.Unlock()
+		next := fmt.Sprintf("https://a/%d", ctx.Req.Depth+1)
+		return spider.ParseResult{Requesrts: []*spider.Request{{URL: next, RuleName: "parse"}}}, nil
+	}
+	fetch := &countFetch{}
+
+	e := NewEngine(
+		WithScheduler(NewSchedule()),
+		WithFetcher(fetch),
+		WithSeeds([]*spider.Task{task}),
+	)
+	summary, err := e.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := fetch.fetched(); len(got) != 3 {
+		t.Fatalf("fetched %v, want depth 0 to 2", got)
+	}
+	if summary.Dropped != 1 {
+		t.Fatalf("dropped = %d, want the depth 3 request", summary.Dropped)
+	}
+	for depth := int64(0); depth <= 2; depth++ {
+		req := parsed[fmt.Sprintf("https://a/%d", depth)]
+		if req == nil || req.Depth != depth || req.Task != task {
+			t.Fatalf("request at depth %d = %+v", depth, req)
+		}
+	}
+}
+
+func TestValidatorRetryIgnoresRetryableStatus(t *testing.T) {
+	task := newListTask("list", "https://a/520")
+	fetch := &countFetch{status: map[string]int{"https://a/520": 520}}
+
+	// 520 不在 RetryableStatus 中, 但 StatusValidator 判定 5xx 重试
+	e := NewEngine(
+		WithScheduler(NewSchedule()),
+		WithFetcher(fetch),
+		WithRetryPolicy(&spider.RetryPolicy{MaxAttempts: 3, RetryableStatus: []int{503}}),
+		WithSeeds([]*spider.Task{task}),
+	)
+	summary, err := e.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := fetch.fetched(); len(got) != 3 {
+		t.Fatalf("fetched %v, want 3 attempts", got)
+	}
+	if summary.Failed != 1 {
+		t.Fatalf("failed = %d", summary.Failed)
+	}
+}
+
+// visitCheckStorage 保存时检查请求是否已经记为访问过.
+type visitCheckStorage struct {
+	mu      sync.Mutex
+	visited dedup.Store
+	keys    map[string]string // URL -> Request.Unique()
+	early   []string
+	saved   int
+}
+
+func (s *visitCheckStorage) Save(datas ...*spider.DataCell) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, d := range datas {
+		url := d.Value.(string)
+		if has, _ := s.visited.Has(s.keys[url]); has {
+			s.early = append(s.early, url)
+		}
+		s.saved++
+	}
+	return nil
+}
+
+func (s *visitCheckStorage) Flush() error { return nil }
+
+func TestVisitedAfterItemsSaved(t *testing.T) {
+	urls := []string{"https://a/1", "https://a/2", "https://a/3"}
+	task := newListTask("list", urls...)
+	task.Rule.Trunk["parse"].ParseFunc = func(ctx *spider.Context) (spider.ParseResult, error) {
+		return spider.ParseResult{Items: []interface{}{ctx.Output(ctx.Req.URL)}}, nil
+	}
+	visited := dedup.NewMemory()
+	storage := &visitCheckStorage{visited: visited, keys: map[string]string{}}
+	for _, u := range urls {
+		storage.keys[u] = (&spider.Request{URL: u, RuleName: "parse", Task: task}).Unique()
+	}
+
+	e := NewEngine(
+		WithScheduler(NewSchedule()),
+		WithFetcher(&countFetch{}),
+		WithDedup(visited),
+		WithStorage(storage),
+		WithSeeds([]*spider.Task{task}),
+	)
+	if _, err := e.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if storage.saved != len(urls) || len(storage.early) != 0 {
+		t.Fatalf("saved %d items, marked visited before save: %v", storage.saved, storage.early)
+	}
+	for _, u := range urls {
+		if has, _ := visited.Has(storage.keys[u]); !has {
+			t.Fatalf("%s not marked visited", u)
+		}
+	}
+}
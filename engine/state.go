@@ -0,0 +1,42 @@
+package engine
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+// ErrShutdownTimeout 收尾超时, 不会保存最后的断点. 此时 worker 和保存结果的协程可能还在运行,
+// 调用方不能关闭传给 engine 的 Storage, DeadLetter 和 Dedup.
+var ErrShutdownTimeout = errors.New("crawler shutdown timeout")
+
+// State 抓取的生命周期: Idle -> Running -> Draining -> Stopped.
+type State int32
+
+const (
+	StateIdle     State = iota // 还没有 Run
+	StateRunning               // 正在抓取
+	StateDraining              // 不再接受请求, 等待 worker 退出并保存剩下的结果
+	StateStopped               // 已经停止, storage 已经 flush
+)
+
+func (s State) String() string {
+	switch s {
+	case StateIdle:
+		return "idle"
+	case StateRunning:
+		return "running"
+	case StateDraining:
+		return "draining"
+	case StateStopped:
+		return "stopped"
+	}
+	return "unknown"
+}
+
+func (c *Crawler) State() State {
+	return State(atomic.LoadInt32(&c.state))
+}
+
+func (c *Crawler) setState(s State) {
+	atomic.StoreInt32(&c.state, int32(s))
+}
@@ -0,0 +1,44 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Ysoding/pokemon-wiki-spider/spider"
+)
+
+// blockStorage Flush 一直阻塞到 release 关闭.
+type blockStorage struct {
+	release chan struct{}
+}
+
+func (s *blockStorage) Save(datas ...*spider.DataCell) error { return nil }
+
+func (s *blockStorage) Flush() error {
+	<-s.release
+	return nil
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	storage := &blockStorage{release: make(chan struct{})}
+	defer close(storage.release)
+
+	e := NewEngine(
+		WithScheduler(NewSchedule()),
+		WithFetcher(&countFetch{}),
+		WithStorage(storage),
+		WithShutdownTimeout(50*time.Millisecond),
+		WithSeeds([]*spider.Task{newListTask("list", "https://a/1")}),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := e.Run(ctx); !errors.Is(err, ErrShutdownTimeout) {
+		t.Fatalf("Run err = %v, want ErrShutdownTimeout", err)
+	}
+	if s := e.State(); s != StateStopped {
+		t.Fatalf("state = %v", s)
+	}
+}
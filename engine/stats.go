@@ -0,0 +1,48 @@
+package engine
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Summary 一次抓取的统计.
+type Summary struct {
+	Requests  int64 // 发出的请求数, 包括重试
+	Succeeded int64 // 抓取并解析成功
+	Failed    int64 // 最终失败
+	Items     int64 // 产出的数据条数
+	Dropped   int64 // 超过 MaxDepth, 任务预算用完或者被中间件丢弃的请求
+	// 不符合 Rule.ItemFields 的数据条数
+	Violations int64
+	Duration   time.Duration
+}
+
+type stats struct {
+	start      int64 // UnixNano
+	requests   int64
+	succeeded  int64
+	failed     int64
+	items      int64
+	dropped    int64
+	violations int64
+}
+
+func (s *stats) begin() {
+	atomic.StoreInt64(&s.start, time.Now().UnixNano())
+}
+
+func (s *stats) summary() Summary {
+	var d time.Duration
+	if start := atomic.LoadInt64(&s.start); start > 0 {
+		d = time.Since(time.Unix(0, start))
+	}
+	return Summary{
+		Requests:   atomic.LoadInt64(&s.requests),
+		Succeeded:  atomic.LoadInt64(&s.succeeded),
+		Failed:     atomic.LoadInt64(&s.failed),
+		Items:      atomic.LoadInt64(&s.items),
+		Dropped:    atomic.LoadInt64(&s.dropped),
+		Violations: atomic.LoadInt64(&s.violations),
+		Duration:   d,
+	}
+}
@@ -0,0 +1,236 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Ysoding/pokemon-wiki-spider/spider"
+	"go.uber.org/zap"
+)
+
+type taskState struct {
+	task      *spider.Task
+	deps      []*taskState
+	pending   int  // 该任务已入队但还没处理完的请求
+	started   bool // 已经开始执行 Root
+	seeded    bool // Root 返回的请求已经入队
+	finishing bool // 正在 flush storage
+	finished  bool
+	failed    bool
+	requests  []*spider.Request // 启动时直接入队的请求, 例如重放或者断点中的请求
+	resumed   bool              // 从断点恢复或者只重放请求, 不执行 Root
+	// 任务自己的重试策略, 没有时为 engine 策略的副本
+	retryPolicy *spider.RetryPolicy
+
+	startTime time.Time
+	pauseBase time.Duration // 任务开始时的 pausedFor, 暂停的时间不计入 MaxDuration
+	fetched   int64         // 已经发起的请求, 包括重试
+	items     int64
+	// 不符合 Rule.ItemFields 的数据条数
+	violations int64
+	exhausted  bool // 预算用完, 剩下的请求直接丢弃
+}
+
+// initTasks 按名字登记本次运行的任务, 恢复断点, 并检查 DependsOn 是否有环. 调用方需持有 pendingLock.
+// 不在本次运行中的依赖视为已经完成, 例如上一次运行已经抓好的列表.
+func (c *Crawler) initTasks() error {
+	c.tasks = make(map[string]*taskState)
+	c.taskOrder = nil
+
+	for _, task := range c.Seeds {
+		c.addTask(task)
+	}
+	for _, req := range c.Requests {
+		_, seeded := c.tasks[req.Task.Name]
+		st := c.addTask(req.Task)
+		// 只因为重放请求加入的任务不重新抓取整个任务
+		if !seeded {
+			st.resumed = true
+		}
+		st.requests = append(st.requests, req)
+	}
+
+	// 断点可能带回不在 Seeds 中的任务, 要在计算依赖之前登记
+	if err := c.restoreCheckpoint(); err != nil {
+		return err
+	}
+
+	for _, st := range c.taskOrder {
+		for _, name := range st.task.DependsOn {
+			dep, ok := c.tasks[name]
+			if !ok {
+				c.Logger.Warn("task dependency not in this run, treat as finished",
+					zap.String("task", st.task.Name),
+					zap.String("dependency", name))
+				continue
+			}
+			st.deps = append(st.deps, dep)
+		}
+	}
+
+	// Kahn 拓扑排序, 剩下的就是环上的任务
+	indegree := make(map[*taskState]int, len(c.taskOrder))
+	for _, st := range c.taskOrder {
+		indegree[st] = len(st.deps)
+	}
+	var queue []*taskState
+	for _, st := range c.taskOrder {
+		if indegree[st] == 0 {
+			queue = append(queue, st)
+		}
+	}
+	sorted := 0
+	for len(queue) > 0 {
+		st := queue[0]
+		queue = queue[1:]
+		sorted++
+		for _, other := range c.taskOrder {
+			for _, dep := range other.deps {
+				if dep == st {
+					if indegree[other]--; indegree[other] == 0 {
+						queue = append(queue, other)
+					}
+				}
+			}
+		}
+	}
+	if sorted != len(c.taskOrder) {
+		var cycle []string
+		for _, st := range c.taskOrder {
+			if indegree[st] > 0 {
+				cycle = append(cycle, st.task.Name)
+			}
+		}
+		return fmt.Errorf("task dependency cycle: %s", strings.Join(cycle, ", "))
+	}
+
+	return nil
+}
+
+// addTask 登记任务, 已经登记过时返回原来的状态. 调用方需持有 pendingLock.
+func (c *Crawler) addTask(task *spider.Task) *taskState {
+	if st, ok := c.tasks[task.Name]; ok {
+		return st
+	}
+	st := &taskState{task: task, retryPolicy: task.RetryPolicy}
+	if st.retryPolicy == nil {
+		st.retryPolicy = c.RetryPolicy.Clone()
+	}
+	c.tasks[task.Name] = st
+	c.taskOrder = append(c.taskOrder, st)
+	return st
+}
+
+// startReadyTasks 启动依赖都已完成的任务, 调用方需持有 pendingLock.
+func (c *Crawler) startReadyTasks() {
+	for changed := true; changed; {
+		changed = false
+		for _, st := range c.taskOrder {
+			if st.started || st.finished {
+				continue
+			}
+
+			ready, depFailed := true, false
+			for _, dep := range st.deps {
+				if !dep.finished {
+					ready = false
+					break
+				}
+				depFailed = depFailed || dep.failed
+			}
+			if !ready {
+				continue
+			}
+
+			if depFailed {
+				c.Logger.Error("skip task, dependency failed", zap.String("task", st.task.Name))
+				st.started, st.finished, st.failed = true, true, true
+				changed = true
+				continue
+			}
+
+			st.started = true
+			st.startTime = time.Now()
+			st.pauseBase = c.pausedFor()
+			go c.startTask(st)
+		}
+	}
+	c.checkDone()
+}
+
+func (c *Crawler) startTask(st *taskState) {
+	task := st.task
+	c.Logger.Info("start task", zap.String("Name", task.Name))
+
+	if c.Fetcher != nil {
+		task.Fetcher = c.Fetcher
+	}
+
+	var reqs []*spider.Request
+	if !st.resumed {
+		roots, err := task.Rule.Root()
+		if err != nil {
+			c.Logger.Error("got task root failed", zap.String("task", task.Name), zap.Error(err))
+			c.pendingLock.Lock()
+			st.failed = true
+			st.seeded = true
+			c.checkTask(st)
+			c.pendingLock.Unlock()
+			return
+		}
+
+		for _, req := range roots {
+			c.Logger.Info("request", zap.String("URL", req.URL))
+			req.Task = task
+		}
+		reqs = roots
+	}
+
+	c.push(append(reqs, st.requests...)...)
+
+	c.pendingLock.Lock()
+	st.seeded = true
+	c.checkTask(st)
+	c.pendingLock.Unlock()
+}
+
+// checkTask 任务的请求都处理完后 flush storage, 然后启动下游任务. 调用方需持有 pendingLock.
+func (c *Crawler) checkTask(st *taskState) {
+	if !st.seeded || st.pending > 0 || st.finishing || st.finished {
+		return
+	}
+	st.finishing = true
+	go c.finishTask(st)
+}
+
+func (c *Crawler) finishTask(st *taskState) {
+	s := c.Storage
+	if st.task.Storage != nil {
+		s = st.task.Storage
+	}
+	if s != nil {
+		if err := s.Flush(); err != nil {
+			c.Logger.Error("task storage flush", zap.String("task", st.task.Name), zap.Error(err))
+		}
+	}
+
+	c.pendingLock.Lock()
+	defer c.pendingLock.Unlock()
+	st.finished = true
+	c.Logger.Info("task finished", zap.String("task", st.task.Name), zap.Bool("failed", st.failed))
+	c.startReadyTasks()
+}
+
+// checkDone 所有任务结束且没有未完成的请求时结束抓取. 调用方需持有 pendingLock.
+func (c *Crawler) checkDone() {
+	if c.pendingCnt > 0 {
+		return
+	}
+	for _, st := range c.taskOrder {
+		if !st.finished {
+			return
+		}
+	}
+	c.markDone()
+}
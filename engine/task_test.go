@@ -0,0 +1,91 @@
+package engine
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/Ysoding/pokemon-wiki-spider/spider"
+)
+
+// countFetch 记录抓取过的 URL, status 中没有的 URL 返回 200.
+type countFetch struct {
+	mu     sync.Mutex
+	urls   []string
+	status map[string]int
+}
+
+func (f *countFetch) Get(ctx context.Context, req *spider.Request) (*spider.Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.urls = append(f.urls, req.URL)
+	code, ok := f.status[req.URL]
+	if !ok {
+		code = 200
+	}
+	return &spider.Response{StatusCode: code}, nil
+}
+
+func (f *countFetch) fetched() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.urls...)
+}
+
+func newListTask(name string, urls ...string) *spider.Task {
+	return &spider.Task{
+		Options: spider.Options{Name: name, MaxDepth: 5},
+		Rule: spider.RuleTree{
+			Root: func() ([]*spider.Request, error) {
+				var reqs []*spider.Request
+				for _, u := range urls {
+					reqs = append(reqs, &spider.Request{URL: u, RuleName: "parse"})
+				}
+				return reqs, nil
+			},
+			Trunk: map[string]*spider.Rule{
+				"parse": {ParseFunc: func(ctx *spider.Context) (spider.ParseResult, error) {
+					return spider.ParseResult{}, nil
+				}},
+			},
+		},
+	}
+}
+
+func TestReplayDoesNotRunRoot(t *testing.T) {
+	task := newListTask("list", "https://a/1", "https://a/2", "https://a/3")
+	fetch := &countFetch{}
+
+	e := NewEngine(
+		WithScheduler(NewSchedule()),
+		WithFetcher(fetch),
+		WithRequests([]*spider.Request{{URL: "https://a/2", RuleName: "parse", Task: task}}),
+	)
+	if _, err := e.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	got := fetch.fetched()
+	if len(got) != 1 || got[0] != "https://a/2" {
+		t.Fatalf("fetched %v, want only the replayed request", got)
+	}
+}
+
+func TestSeedWithRequestsRunsRoot(t *testing.T) {
+	task := newListTask("list", "https://a/1", "https://a/2")
+	fetch := &countFetch{}
+
+	e := NewEngine(
+		WithScheduler(NewSchedule()),
+		WithFetcher(fetch),
+		WithSeeds([]*spider.Task{task}),
+		WithRequests([]*spider.Request{{URL: "https://a/3", RuleName: "parse", Task: task}}),
+	)
+	if _, err := e.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := fetch.fetched(); len(got) != 3 {
+		t.Fatalf("fetched %v, want roots and the replayed request", got)
+	}
+}
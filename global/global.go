@@ -1,5 +1,7 @@
 package global
 
+import "time"
+
 var (
 	EnableMongoDB            = true
 	DefaultMongoDatabaseName = "pokemon"
@@ -11,12 +13,39 @@ var (
 	PokemonMoveListURL       = "https://wiki.52poke.com/zh-hans/招式列表"
 	PokemonItemListURL       = "https://wiki.52poke.com/zh-hans/道具列表"
 
-	PokemonListTaskName    = "pokemon_list"
-	PokemonDetailTaskName  = "pokemon_detail"
-	PokemonMoveListName    = "move_list"
-	PokemonAbilityListName = "ability_list"
+	PokemonListTaskName           = "pokemon_list"
+	PokemonDetailTaskName         = "pokemon_detail"
+	PokemonMoveListName           = "move_list"
+	PokemonMoveDetailName         = "move_detail"
+	PokemonAbilityListName        = "ability_list"
+	PokemonAbilityDetailName      = "ability_detail"
+	PokemonAbilityPokemonListName = "pokemon_ability_list"
+	PokemonNatureListName         = "nature_list"
+	PokemonItemListName           = "item_list"
+
+	// 52poke 条目页面都比较大, 太短说明没有加载完整
+	MinWikiPageLength = 6000
+	// 页面不存在
+	WikiMissingPageMarkers = []string{"此页面目前没有内容", "此页目前没有内容"}
+	// 维护或者被 Cloudflare 拦截
+	WikiMaintenanceMarkers = []string{"数据库已被锁定", "Just a moment..."}
 
-	DefaultWorkerCount = 16
+	DefaultWorkerCount        = 16
+	DefaultMaxPerHost         = 8
+	DefaultResultWorkers      = 4
+	DefaultResultBuffer       = 64
+	WikiHost                  = "wiki.52poke.com"
+	WikiMaxPerHost            = 4
+	DefaultAgingInterval      = 30 * time.Second
+	DefaultRetryPriority      = 1
+	ListPriority              = 10 // 列表页先于详情页抓取
+	DefaultDeadLetterFile     = "./logs/dead_letter.jsonl"
+	DefaultCheckpointFile     = "./logs/checkpoint.json"
+	DefaultCheckpointInterval = 30 * time.Second
+	DefaultShutdownTimeout    = 30 * time.Second
+	DefaultDedupFile          = "./logs/visited"
+	DefaultBloomCapacity      = uint64(1000000)
+	DefaultBloomFalsePositive = 0.001
 
 	LocationNameList = []string{
 		"关都",
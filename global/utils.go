@@ -1,29 +0,0 @@
-package global
-
-import (
-	"reflect"
-)
-
-func StructToMap(data interface{}) map[string]interface{} {
-	result := make(map[string]interface{})
-
-	v := reflect.ValueOf(data)
-	t := reflect.TypeOf(data)
-
-	if v.Kind() == reflect.Ptr {
-		v = v.Elem()
-		t = t.Elem()
-	}
-
-	if v.Kind() != reflect.Struct {
-		return nil
-	}
-
-	for i := 0; i < v.NumField(); i++ {
-		field := v.Field(i)
-		fieldName := t.Field(i).Name
-		result[fieldName] = field.Interface()
-	}
-
-	return result
-}
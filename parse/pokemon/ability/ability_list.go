@@ -8,6 +8,7 @@ import (
 
 	"github.com/PuerkitoBio/goquery"
 	"github.com/Ysoding/pokemon-wiki-spider/global"
+	"github.com/Ysoding/pokemon-wiki-spider/parse/wiki"
 	"github.com/Ysoding/pokemon-wiki-spider/spider"
 )
 
@@ -22,12 +23,28 @@ type AbilityData struct {
 	Generation  int
 }
 
+var abilityListFields = spider.Schema{
+	{Name: "Index", Type: spider.FieldInt, Required: true},
+	{Name: "NameZh", Type: spider.FieldString, Required: true},
+	{Name: "NameJa", Type: spider.FieldString},
+	{Name: "NameEn", Type: spider.FieldString},
+	{Name: "Description", Type: spider.FieldString, Required: true},
+	{Name: "CommonCnt", Type: spider.FieldInt},
+	{Name: "HiddenCnt", Type: spider.FieldInt},
+	{Name: "Generation", Type: spider.FieldInt, Required: true},
+}
+
+func init() {
+	spider.Register(AbilityListTask)
+}
+
 var AbilityListTask = &spider.Task{
 	Options: spider.Options{
-		Name:     global.PokemonAbilityListName,
-		Cookie:   "",
-		MaxDepth: 5,
-		WaitTime: 0,
+		Name:      global.PokemonAbilityListName,
+		Validator: wiki.Validator,
+		Cookie:    "",
+		MaxDepth:  5,
+		WaitTime:  0,
 	},
 	Rule: spider.RuleTree{
 		Root: func() ([]*spider.Request, error) {
@@ -36,13 +53,14 @@ var AbilityListTask = &spider.Task{
 					URL:      global.AbilityListURL,
 					Method:   "GET",
 					RuleName: "list",
+					Priority: global.ListPriority,
 				},
 			}
 			return roots, nil
 		},
 
 		Trunk: map[string]*spider.Rule{
-			"list": {ParseFunc: parseAbilityList},
+			"list": {ItemFields: abilityListFields, ParseFunc: parseAbilityList},
 		},
 	},
 }
@@ -67,7 +85,7 @@ func parseAbilityList(ctx *spider.Context) (spider.ParseResult, error) {
 
 	var result []interface{}
 	for _, value := range items {
-		result = append(result, ctx.Output(global.StructToMap(value)))
+		result = append(result, ctx.Output(value))
 	}
 
 	return spider.ParseResult{
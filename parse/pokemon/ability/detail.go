@@ -10,6 +10,7 @@ import (
 	"github.com/Ysoding/pokemon-wiki-spider/db/mongodb"
 	"github.com/Ysoding/pokemon-wiki-spider/global"
 	"github.com/Ysoding/pokemon-wiki-spider/limiter"
+	"github.com/Ysoding/pokemon-wiki-spider/parse/wiki"
 	"github.com/Ysoding/pokemon-wiki-spider/spider"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.uber.org/zap"
@@ -24,27 +25,54 @@ type AbilityDetailData struct {
 	Owners []string // 拥有此特性的宝可梦
 }
 
+var abilityDetailFields = spider.Schema{
+	{Name: "Index", Type: spider.FieldInt, Required: true},
+	{Name: "NameZh", Type: spider.FieldString, Required: true},
+	{Name: "Desc", Type: spider.FieldString, Required: true},
+	{Name: "Effect", Type: spider.FieldString},
+	{Name: "Owners", Type: spider.FieldList},
+}
+
+func init() {
+	spider.Register(MoveDetailTask)
+}
+
+// roots 放进请求的列表数据
+var (
+	indexKey  = spider.Key[int]("index")
+	nameZhKey = spider.Key[string]("nameZh")
+)
+
 var MoveDetailTask = &spider.Task{
 	Options: spider.Options{
-		Name:     "ability_detail",
-		Cookie:   "",
-		MaxDepth: 5,
-		WaitTime: 3,
+		Name:      global.PokemonAbilityDetailName,
+		Validator: wiki.Validator,
+		Cookie:    "",
+		MaxDepth:  5,
+		WaitTime:  3,
+		DependsOn: []string{global.PokemonAbilityListName},
 		Limit: limiter.Multi(
 			rate.NewLimiter(limiter.Per(1, 1*time.Second), 1),
 		),
+		Processors: []spider.ItemProcessor{spider.TrimNewlines("Effect")},
 	},
 	Rule: spider.RuleTree{
 		Root: roots,
 		Trunk: map[string]*spider.Rule{
-			"parse": {ParseFunc: parseAbilityDetail},
+			"parse": {ItemFields: abilityDetailFields, ParseFunc: parseAbilityDetail},
 		},
 	},
 }
 
 func parseAbilityDetail(ctx *spider.Context) (spider.ParseResult, error) {
-	index, _ := ctx.Req.TempData.Get("index").(int)
-	nameZh, _ := ctx.Req.TempData.Get("nameZh").(string)
+	index, err := indexKey.Get(ctx.Req.TempData)
+	if err != nil {
+		return spider.ParseResult{}, err
+	}
+	nameZh, err := nameZhKey.Get(ctx.Req.TempData)
+	if err != nil {
+		return spider.ParseResult{}, err
+	}
 
 	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(ctx.Body))
 	if err != nil {
@@ -71,13 +99,13 @@ func parseAbilityDetail(ctx *spider.Context) (spider.ParseResult, error) {
 	})
 
 	var result []interface{}
-	result = append(result, ctx.Output(global.StructToMap(&AbilityDetailData{
+	result = append(result, ctx.Output(&AbilityDetailData{
 		Index:  index,
 		NameZh: nameZh,
 		Desc:   desc,
 		Effect: effect,
 		Owners: pokemonList,
-	})))
+	}))
 
 	return spider.ParseResult{
 		Requesrts: make([]*spider.Request, 0),
@@ -115,12 +143,12 @@ func roots() ([]*spider.Request, error) {
 		}
 		req.TempData = &spider.TempData{}
 
-		if err := req.TempData.Set("index", d.Index); err != nil {
+		if err := indexKey.Set(req.TempData, d.Index); err != nil {
 			zap.L().Error("set temp data error", zap.Error(err))
 			continue
 		}
 
-		if err := req.TempData.Set("nameZh", d.NameZh); err != nil {
+		if err := nameZhKey.Set(req.TempData, d.NameZh); err != nil {
 			zap.L().Error("set temp data error", zap.Error(err))
 			continue
 		}
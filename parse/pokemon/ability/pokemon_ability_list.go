@@ -8,6 +8,7 @@ import (
 
 	"github.com/PuerkitoBio/goquery"
 	"github.com/Ysoding/pokemon-wiki-spider/global"
+	"github.com/Ysoding/pokemon-wiki-spider/parse/wiki"
 	"github.com/Ysoding/pokemon-wiki-spider/spider"
 )
 
@@ -23,12 +24,29 @@ type PokemonAbilityData struct {
 	Generation  int
 }
 
+var pokemonAbilityListFields = spider.Schema{
+	{Name: "Index", Type: spider.FieldInt, Required: true},
+	{Name: "NameZh", Type: spider.FieldString, Required: true},
+	{Name: "Form", Type: spider.FieldString},
+	{Name: "Type1", Type: spider.FieldString, Required: true},
+	{Name: "Type2", Type: spider.FieldString},
+	{Name: "Ability1", Type: spider.FieldString, Required: true},
+	{Name: "Ability2", Type: spider.FieldString},
+	{Name: "HideAbility", Type: spider.FieldString},
+	{Name: "Generation", Type: spider.FieldInt, Required: true},
+}
+
+func init() {
+	spider.Register(PokemonAbilityListTask)
+}
+
 var PokemonAbilityListTask = &spider.Task{
 	Options: spider.Options{
-		Name:     "pokemon_ability_list",
-		Cookie:   "",
-		MaxDepth: 5,
-		WaitTime: 0,
+		Name:      global.PokemonAbilityPokemonListName,
+		Validator: wiki.Validator,
+		Cookie:    "",
+		MaxDepth:  5,
+		WaitTime:  0,
 	},
 	Rule: spider.RuleTree{
 		Root: func() ([]*spider.Request, error) {
@@ -37,13 +55,14 @@ var PokemonAbilityListTask = &spider.Task{
 					URL:      global.PokemonAbilityListURL,
 					Method:   "GET",
 					RuleName: "list",
+					Priority: global.ListPriority,
 				},
 			}
 			return roots, nil
 		},
 
 		Trunk: map[string]*spider.Rule{
-			"list": {ParseFunc: parsePokemonAbilityList},
+			"list": {ItemFields: pokemonAbilityListFields, ParseFunc: parsePokemonAbilityList},
 		},
 	},
 }
@@ -62,7 +81,7 @@ func parsePokemonAbilityList(ctx *spider.Context) (spider.ParseResult, error) {
 
 	var result []interface{}
 	for _, value := range items {
-		result = append(result, ctx.Output(global.StructToMap(value)))
+		result = append(result, ctx.Output(value))
 	}
 
 	return spider.ParseResult{
@@ -12,6 +12,7 @@ import (
 	"github.com/Ysoding/pokemon-wiki-spider/db/mongodb"
 	"github.com/Ysoding/pokemon-wiki-spider/global"
 	"github.com/Ysoding/pokemon-wiki-spider/limiter"
+	"github.com/Ysoding/pokemon-wiki-spider/parse/wiki"
 	"github.com/Ysoding/pokemon-wiki-spider/spider"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.uber.org/zap"
@@ -40,6 +41,28 @@ type PokemonDetailData struct {
 	EggMoveList         []EggMove         // 蛋招式
 }
 
+var pokemonDetailFields = spider.Schema{
+	{Name: "Index", Type: spider.FieldInt, Required: true},
+	{Name: "NameZh", Type: spider.FieldString, Required: true},
+	{Name: "ImgURL", Type: spider.FieldString},
+	{Name: "Type", Type: spider.FieldString, Required: true},
+	{Name: "Category", Type: spider.FieldString, Required: true},
+	{Name: "Ability", Type: spider.FieldString, Required: true},
+	{Name: "Height", Type: spider.FieldString, Unit: "m"},
+	{Name: "Weight", Type: spider.FieldString, Unit: "kg"},
+	{Name: "BodyStyle", Type: spider.FieldString},
+	{Name: "CatchRate", Type: spider.FieldString},
+	{Name: "GenderRatio", Type: spider.FieldString},
+	{Name: "EggGroup1", Type: spider.FieldString},
+	{Name: "EggGroup2", Type: spider.FieldString},
+	{Name: "HatchTime", Type: spider.FieldString},
+	{Name: "EffortValue", Type: spider.FieldString},
+	{Name: "BaseStat", Type: spider.FieldObject},
+	{Name: "LearnableMovesList", Type: spider.FieldList},
+	{Name: "UsableMoveTutorList", Type: spider.FieldList},
+	{Name: "EggMoveList", Type: spider.FieldList},
+}
+
 type BaseStat struct {
 	HP        int
 	Attack    int
@@ -82,12 +105,20 @@ type EggMove struct {
 	PP       string
 }
 
+// roots 放进请求的列表数据
+var (
+	indexKey  = spider.Key[int]("index")
+	nameZhKey = spider.Key[string]("nameZh")
+)
+
 var PokemonDetailTask = &spider.Task{
 	Options: spider.Options{
-		Name:     global.PokemonDetailTaskName,
-		Cookie:   "",
-		MaxDepth: 5,
-		WaitTime: 3,
+		Name:      global.PokemonDetailTaskName,
+		Validator: wiki.Validator,
+		Cookie:    "",
+		MaxDepth:  5,
+		WaitTime:  3,
+		DependsOn: []string{global.PokemonListTaskName},
 		Limit: limiter.Multi(
 			rate.NewLimiter(limiter.Per(1, 1*time.Second), 1),
 		),
@@ -98,7 +129,7 @@ var PokemonDetailTask = &spider.Task{
 		},
 
 		Trunk: map[string]*spider.Rule{
-			"parse": {ParseFunc: parsePokemonDetail},
+			"parse": {ItemFields: pokemonDetailFields, ParseFunc: parsePokemonDetail},
 		},
 	},
 }
@@ -126,12 +157,12 @@ func roots() ([]*spider.Request, error) {
 		}
 		req.TempData = &spider.TempData{}
 
-		if err := req.TempData.Set("index", d.Index); err != nil {
+		if err := indexKey.Set(req.TempData, d.Index); err != nil {
 			zap.L().Error("set temp data error", zap.Error(err))
 			continue
 		}
 
-		if err := req.TempData.Set("nameZh", d.NameZh); err != nil {
+		if err := nameZhKey.Set(req.TempData, d.NameZh); err != nil {
 			zap.L().Error("set temp data error", zap.Error(err))
 			continue
 		}
@@ -148,9 +179,15 @@ func parsePokemonDetail(ctx *spider.Context) (spider.ParseResult, error) {
 		return spider.ParseResult{}, err
 	}
 
-	index, _ := ctx.Req.TempData.Get("index").(int)
+	index, err := indexKey.Get(ctx.Req.TempData)
+	if err != nil {
+		return spider.ParseResult{}, err
+	}
+	nameZh, err := nameZhKey.Get(ctx.Req.TempData)
+	if err != nil {
+		return spider.ParseResult{}, err
+	}
 	indexStr := fmt.Sprintf("%03d", index)
-	nameZh, _ := ctx.Req.TempData.Get("nameZh").(string)
 
 	table := doc.Find("#mw-content-text > .mw-parser-output > table").Eq(1)
 
@@ -217,7 +254,7 @@ func parsePokemonDetail(ctx *spider.Context) (spider.ParseResult, error) {
 	effortValue := formatStr(strings.Join(effortValueList, ","))
 
 	var result []interface{}
-	result = append(result, ctx.Output(global.StructToMap(&PokemonDetailData{
+	result = append(result, ctx.Output(&PokemonDetailData{
 		Index:               index,
 		NameZh:              nameZh,
 		ImgURL:              imgURL,
@@ -237,7 +274,7 @@ func parsePokemonDetail(ctx *spider.Context) (spider.ParseResult, error) {
 		LearnableMovesList:  parseLearnableMovesList(doc),
 		UsableMoveTutorList: parseUsableMoveTutorList(doc),
 		EggMoveList:         parseEggMoveList(doc, nameZh),
-	})))
+	}))
 
 	return spider.ParseResult{
 		Requesrts: make([]*spider.Request, 0),
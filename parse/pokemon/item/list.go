@@ -8,6 +8,7 @@ import (
 
 	"github.com/PuerkitoBio/goquery"
 	"github.com/Ysoding/pokemon-wiki-spider/global"
+	"github.com/Ysoding/pokemon-wiki-spider/parse/wiki"
 	"github.com/Ysoding/pokemon-wiki-spider/spider"
 )
 
@@ -20,12 +21,26 @@ type Data struct {
 	ImageURL    string
 }
 
+var itemListFields = spider.Schema{
+	{Name: "NameZh", Type: spider.FieldString, Required: true},
+	{Name: "NameJa", Type: spider.FieldString},
+	{Name: "NameEn", Type: spider.FieldString},
+	{Name: "Type", Type: spider.FieldString, Required: true},
+	{Name: "Description", Type: spider.FieldString},
+	{Name: "ImageURL", Type: spider.FieldString},
+}
+
+func init() {
+	spider.Register(ItemListTask)
+}
+
 var ItemListTask = &spider.Task{
 	Options: spider.Options{
-		Name:     "item_list",
-		Cookie:   "",
-		MaxDepth: 5,
-		WaitTime: 0,
+		Name:      global.PokemonItemListName,
+		Validator: wiki.Validator,
+		Cookie:    "",
+		MaxDepth:  5,
+		WaitTime:  0,
 	},
 	Rule: spider.RuleTree{
 		Root: func() ([]*spider.Request, error) {
@@ -34,13 +49,14 @@ var ItemListTask = &spider.Task{
 					URL:      global.PokemonItemListURL,
 					Method:   "GET",
 					RuleName: "list",
+					Priority: global.ListPriority,
 				},
 			}
 			return roots, nil
 		},
 
 		Trunk: map[string]*spider.Rule{
-			"list": {ParseFunc: ParsePokemonItemList},
+			"list": {ItemFields: itemListFields, ParseFunc: ParsePokemonItemList},
 		},
 	},
 }
@@ -86,7 +102,7 @@ func ParsePokemonItemList(ctx *spider.Context) (spider.ParseResult, error) {
 
 	var result []interface{}
 	for _, value := range items {
-		result = append(result, ctx.Output(global.StructToMap(*value)))
+		result = append(result, ctx.Output(value))
 	}
 
 	return spider.ParseResult{
@@ -8,6 +8,7 @@ import (
 
 	"github.com/PuerkitoBio/goquery"
 	"github.com/Ysoding/pokemon-wiki-spider/global"
+	"github.com/Ysoding/pokemon-wiki-spider/parse/wiki"
 	"github.com/Ysoding/pokemon-wiki-spider/spider"
 )
 
@@ -22,12 +23,24 @@ type PokemonListData struct {
 	Generation int
 }
 
+var pokemonListFields = spider.Schema{
+	{Name: "Index", Type: spider.FieldInt, Required: true},
+	{Name: "NameZh", Type: spider.FieldString, Required: true},
+	{Name: "NameJa", Type: spider.FieldString},
+	{Name: "NameEn", Type: spider.FieldString},
+	{Name: "Form", Type: spider.FieldString},
+	{Name: "Type1", Type: spider.FieldString, Required: true},
+	{Name: "Type2", Type: spider.FieldString},
+	{Name: "Generation", Type: spider.FieldInt, Required: true},
+}
+
 var PokemonListTask = &spider.Task{
 	Options: spider.Options{
-		Name:     global.PokemonListTaskName,
-		Cookie:   "",
-		MaxDepth: 5,
-		WaitTime: 0,
+		Name:      global.PokemonListTaskName,
+		Validator: wiki.Validator,
+		Cookie:    "",
+		MaxDepth:  5,
+		WaitTime:  0,
 	},
 	Rule: spider.RuleTree{
 		Root: func() ([]*spider.Request, error) {
@@ -36,13 +49,14 @@ var PokemonListTask = &spider.Task{
 					URL:      global.PokemonListURL,
 					Method:   "GET",
 					RuleName: "list",
+					Priority: global.ListPriority,
 				},
 			}
 			return roots, nil
 		},
 
 		Trunk: map[string]*spider.Rule{
-			"list": {ParseFunc: ParsePokemonList},
+			"list": {ItemFields: pokemonListFields, ParseFunc: ParsePokemonList},
 		},
 	},
 }
@@ -61,7 +75,7 @@ func ParsePokemonList(ctx *spider.Context) (spider.ParseResult, error) {
 
 	var result []interface{}
 	for _, value := range items {
-		result = append(result, ctx.Output(global.StructToMap(value)))
+		result = append(result, ctx.Output(value))
 	}
 
 	return spider.ParseResult{
@@ -12,6 +12,7 @@ import (
 	"github.com/Ysoding/pokemon-wiki-spider/db/mongodb"
 	"github.com/Ysoding/pokemon-wiki-spider/global"
 	"github.com/Ysoding/pokemon-wiki-spider/limiter"
+	"github.com/Ysoding/pokemon-wiki-spider/parse/wiki"
 	"github.com/Ysoding/pokemon-wiki-spider/spider"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.uber.org/zap"
@@ -28,27 +29,56 @@ type MoveDetailData struct {
 	Effect string
 }
 
+var moveDetailFields = spider.Schema{
+	{Name: "Index", Type: spider.FieldInt, Required: true},
+	{Name: "NameZh", Type: spider.FieldString, Required: true},
+	{Name: "Desc", Type: spider.FieldString, Required: true},
+	{Name: "ImgUrl", Type: spider.FieldString},
+	{Name: "Notes", Type: spider.FieldString},
+	{Name: "Scope", Type: spider.FieldString},
+	{Name: "Effect", Type: spider.FieldString},
+}
+
+func init() {
+	spider.Register(MoveDetailTask)
+}
+
+// roots 放进请求的列表数据
+var (
+	indexKey  = spider.Key[int]("index")
+	nameZhKey = spider.Key[string]("nameZh")
+)
+
 var MoveDetailTask = &spider.Task{
 	Options: spider.Options{
-		Name:     "move_detail",
-		Cookie:   "",
-		MaxDepth: 5,
-		WaitTime: 3,
+		Name:      global.PokemonMoveDetailName,
+		Validator: wiki.Validator,
+		Cookie:    "",
+		MaxDepth:  5,
+		WaitTime:  3,
+		DependsOn: []string{global.PokemonMoveListName},
 		Limit: limiter.Multi(
 			rate.NewLimiter(limiter.Per(1, 1*time.Second), 1),
 		),
+		Processors: []spider.ItemProcessor{spider.TrimNewlines("Effect")},
 	},
 	Rule: spider.RuleTree{
 		Root: roots,
 		Trunk: map[string]*spider.Rule{
-			"parse": {ParseFunc: parseMoveDetail},
+			"parse": {ItemFields: moveDetailFields, ParseFunc: parseMoveDetail},
 		},
 	},
 }
 
 func parseMoveDetail(ctx *spider.Context) (spider.ParseResult, error) {
-	index, _ := ctx.Req.TempData.Get("index").(int)
-	nameZh, _ := ctx.Req.TempData.Get("nameZh").(string)
+	index, err := indexKey.Get(ctx.Req.TempData)
+	if err != nil {
+		return spider.ParseResult{}, err
+	}
+	nameZh, err := nameZhKey.Get(ctx.Req.TempData)
+	if err != nil {
+		return spider.ParseResult{}, err
+	}
 
 	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(ctx.Body))
 	if err != nil {
@@ -82,7 +112,7 @@ func parseMoveDetail(ctx *spider.Context) (spider.ParseResult, error) {
 	}
 
 	var result []interface{}
-	result = append(result, ctx.Output(global.StructToMap(&MoveDetailData{
+	result = append(result, ctx.Output(&MoveDetailData{
 		Index:  index,
 		NameZh: nameZh,
 		Desc:   desc,
@@ -90,7 +120,7 @@ func parseMoveDetail(ctx *spider.Context) (spider.ParseResult, error) {
 		Notes:  notes,
 		Scope:  scope,
 		Effect: effect,
-	})))
+	}))
 
 	return spider.ParseResult{
 		Requesrts: make([]*spider.Request, 0),
@@ -119,12 +149,12 @@ func roots() ([]*spider.Request, error) {
 		}
 		req.TempData = &spider.TempData{}
 
-		if err := req.TempData.Set("index", d.Index); err != nil {
+		if err := indexKey.Set(req.TempData, d.Index); err != nil {
 			zap.L().Error("set temp data error", zap.Error(err))
 			continue
 		}
 
-		if err := req.TempData.Set("nameZh", d.NameZh); err != nil {
+		if err := nameZhKey.Set(req.TempData, d.NameZh); err != nil {
 			zap.L().Error("set temp data error", zap.Error(err))
 			continue
 		}
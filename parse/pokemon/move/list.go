@@ -8,6 +8,7 @@ import (
 
 	"github.com/PuerkitoBio/goquery"
 	"github.com/Ysoding/pokemon-wiki-spider/global"
+	"github.com/Ysoding/pokemon-wiki-spider/parse/wiki"
 	"github.com/Ysoding/pokemon-wiki-spider/spider"
 )
 
@@ -25,12 +26,31 @@ type MoveListData struct {
 	Generation  int
 }
 
+var moveListFields = spider.Schema{
+	{Name: "Index", Type: spider.FieldInt, Required: true},
+	{Name: "NameZh", Type: spider.FieldString, Required: true},
+	{Name: "NameJa", Type: spider.FieldString},
+	{Name: "NameEn", Type: spider.FieldString},
+	{Name: "Type", Type: spider.FieldString, Required: true},
+	{Name: "Category", Type: spider.FieldString, Required: true},
+	{Name: "Power", Type: spider.FieldString},
+	{Name: "Accuracy", Type: spider.FieldString},
+	{Name: "PP", Type: spider.FieldString},
+	{Name: "Description", Type: spider.FieldString},
+	{Name: "Generation", Type: spider.FieldInt, Required: true},
+}
+
+func init() {
+	spider.Register(MoveListTask)
+}
+
 var MoveListTask = &spider.Task{
 	Options: spider.Options{
-		Name:     global.PokemonMoveListName,
-		Cookie:   "",
-		MaxDepth: 5,
-		WaitTime: 0,
+		Name:      global.PokemonMoveListName,
+		Validator: wiki.Validator,
+		Cookie:    "",
+		MaxDepth:  5,
+		WaitTime:  0,
 	},
 	Rule: spider.RuleTree{
 		Root: func() ([]*spider.Request, error) {
@@ -39,13 +59,14 @@ var MoveListTask = &spider.Task{
 					URL:      global.PokemonMoveListURL,
 					Method:   "GET",
 					RuleName: "list",
+					Priority: global.ListPriority,
 				},
 			}
 			return roots, nil
 		},
 
 		Trunk: map[string]*spider.Rule{
-			"list": {ParseFunc: ParsePokemonMoveList},
+			"list": {ItemFields: moveListFields, ParseFunc: ParsePokemonMoveList},
 		},
 	},
 }
@@ -64,7 +85,7 @@ func ParsePokemonMoveList(ctx *spider.Context) (spider.ParseResult, error) {
 
 	var result []interface{}
 	for _, value := range items {
-		result = append(result, ctx.Output(global.StructToMap(value)))
+		result = append(result, ctx.Output(value))
 	}
 
 	return spider.ParseResult{
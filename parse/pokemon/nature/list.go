@@ -7,6 +7,7 @@ import (
 
 	"github.com/PuerkitoBio/goquery"
 	"github.com/Ysoding/pokemon-wiki-spider/global"
+	"github.com/Ysoding/pokemon-wiki-spider/parse/wiki"
 	"github.com/Ysoding/pokemon-wiki-spider/spider"
 )
 
@@ -20,12 +21,27 @@ type Data struct {
 	DislikedTaste     string
 }
 
+var natureListFields = spider.Schema{
+	{Name: "NameZh", Type: spider.FieldString, Required: true},
+	{Name: "NameJa", Type: spider.FieldString},
+	{Name: "NameEn", Type: spider.FieldString},
+	{Name: "EasyGrowthAbility", Type: spider.FieldString},
+	{Name: "HardGrowthAbility", Type: spider.FieldString},
+	{Name: "FavoriteTaste", Type: spider.FieldString},
+	{Name: "DislikedTaste", Type: spider.FieldString},
+}
+
+func init() {
+	spider.Register(PokemonNatureListTask)
+}
+
 var PokemonNatureListTask = &spider.Task{
 	Options: spider.Options{
-		Name:     "nature_list",
-		Cookie:   "",
-		MaxDepth: 5,
-		WaitTime: 0,
+		Name:      global.PokemonNatureListName,
+		Validator: wiki.Validator,
+		Cookie:    "",
+		MaxDepth:  5,
+		WaitTime:  0,
 	},
 	Rule: spider.RuleTree{
 		Root: func() ([]*spider.Request, error) {
@@ -34,13 +50,14 @@ var PokemonNatureListTask = &spider.Task{
 					URL:      global.PokemonNatureListURL,
 					Method:   "GET",
 					RuleName: "list",
+					Priority: global.ListPriority,
 				},
 			}
 			return roots, nil
 		},
 
 		Trunk: map[string]*spider.Rule{
-			"list": {ParseFunc: ParsePokemonNatureList},
+			"list": {ItemFields: natureListFields, ParseFunc: ParsePokemonNatureList},
 		},
 	},
 }
@@ -57,7 +74,7 @@ func ParsePokemonNatureList(ctx *spider.Context) (spider.ParseResult, error) {
 
 	var result []interface{}
 	for _, value := range items {
-		result = append(result, ctx.Output(global.StructToMap(value)))
+		result = append(result, ctx.Output(value))
 	}
 
 	return spider.ParseResult{
@@ -1,17 +1,14 @@
 package pokemon
 
 import (
+	// 导入即注册各个任务
+	_ "github.com/Ysoding/pokemon-wiki-spider/parse/pokemon/ability"
+	_ "github.com/Ysoding/pokemon-wiki-spider/parse/pokemon/item"
+	_ "github.com/Ysoding/pokemon-wiki-spider/parse/pokemon/move"
+	_ "github.com/Ysoding/pokemon-wiki-spider/parse/pokemon/nature"
 	"github.com/Ysoding/pokemon-wiki-spider/spider"
 )
 
-var Tasks = []*spider.Task{
-	// PokemonListTask,
-	// ability.PokemonAbilityListTask,
-	// ability.AbilityListTask,
-	// nature.PokemonNatureListTask,
-	// move.MoveListTask,
-	// item.ItemListTask,
-	PokemonDetailTask,
-	// move.MoveDetailTask,
-	// ability.MoveDetailTask,
+func init() {
+	spider.Register(PokemonListTask, PokemonDetailTask)
 }
@@ -0,0 +1,167 @@
+package table
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Ysoding/pokemon-wiki-spider/limiter"
+	"github.com/Ysoding/pokemon-wiki-spider/spider"
+	"golang.org/x/time/rate"
+)
+
+// Config 配置文件的格式, 每个 TaskConfig 编译成一个 spider.Task.
+type Config struct {
+	Tasks []TaskConfig `json:"tasks"`
+}
+
+// TaskConfig 抓取页面上的表格, 每行一条数据.
+type TaskConfig struct {
+	Name      string   `json:"name"`
+	URLs      []string `json:"urls"`
+	WaitTime  int64    `json:"wait_time"`  // 请求前随机等待的最长秒数
+	Interval  float64  `json:"interval"`   // 两次请求的最短间隔秒数, 0 不限制
+	DependsOn []string `json:"depends_on"` // 见 spider.Options.DependsOn
+	Weight    int      `json:"weight"`
+
+	Table    string   `json:"table"`     // 表格的选择器, 默认 "table"
+	Tables   []int    `json:"tables"`    // 只取第几个表格, 从 0 开始, 为空时取所有匹配的表格
+	Rows     string   `json:"rows"`      // 表格内行的选择器, 默认 "tr"
+	SkipRows int      `json:"skip_rows"` // 每个表格跳过的表头行数
+	Columns  []Column `json:"columns"`
+}
+
+// Column 把一列映射到一个字段. 列号按展开 rowspan 和 colspan 之后的位置计算.
+type Column struct {
+	Field    string           `json:"field"`
+	Index    int              `json:"index"`
+	Selector string           `json:"selector"` // 单元格内的选择器, 为空时使用单元格本身
+	Attr     string           `json:"attr"`     // 取属性, 为空时取文本
+	Replace  []Replace        `json:"replace"`  // 取值之后按顺序做字符串替换, 例如补全图片链接
+	Type     spider.FieldType `json:"type"`     // string, int, float, 默认 string
+	Required bool             `json:"required"`
+	Unit     string           `json:"unit"`
+}
+
+// Replace 把 Old 全部替换成 New, 后面的替换作用在前面替换的结果上.
+type Replace struct {
+	Old string `json:"old"`
+	New string `json:"new"`
+}
+
+// Load 读取配置文件并编译成任务.
+func Load(path string) ([]*spider.Task, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	// 拼错的字段名直接报错, 不要悄悄生成空表
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+
+	var cfg Config
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	tasks := make([]*spider.Task, 0, len(cfg.Tasks))
+	names := make(map[string]bool, len(cfg.Tasks))
+	for _, tc := range cfg.Tasks {
+		task, err := Compile(tc)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if names[task.Name] {
+			return nil, fmt.Errorf("%s: duplicate task %s", path, task.Name)
+		}
+		names[task.Name] = true
+		tasks = append(tasks, task)
+	}
+	return tasks, nil
+}
+
+// Compile 检查配置并生成任务, 数据写入和任务同名的表.
+func Compile(tc TaskConfig) (*spider.Task, error) {
+	if tc.Name == "" {
+		return nil, fmt.Errorf("task without name")
+	}
+	if len(tc.URLs) == 0 {
+		return nil, fmt.Errorf("task %s: no urls", tc.Name)
+	}
+	if len(tc.Columns) == 0 {
+		return nil, fmt.Errorf("task %s: no columns", tc.Name)
+	}
+	if tc.Table == "" {
+		tc.Table = "table"
+	}
+	if tc.Rows == "" {
+		tc.Rows = "tr"
+	}
+
+	var schema spider.Schema
+	seen := make(map[string]bool)
+	for _, col := range tc.Columns {
+		if col.Field == "" {
+			return nil, fmt.Errorf("task %s: column %d without field", tc.Name, col.Index)
+		}
+		if seen[col.Field] {
+			return nil, fmt.Errorf("task %s: duplicate field %s", tc.Name, col.Field)
+		}
+		seen[col.Field] = true
+		if col.Index < 0 {
+			return nil, fmt.Errorf("task %s: field %s: negative column index", tc.Name, col.Field)
+		}
+		for _, r := range col.Replace {
+			if r.Old == "" {
+				return nil, fmt.Errorf("task %s: field %s: replace without old", tc.Name, col.Field)
+			}
+		}
+		switch col.Type {
+		case spider.FieldAny, spider.FieldString, spider.FieldInt, spider.FieldFloat:
+		default:
+			return nil, fmt.Errorf("task %s: field %s: unsupported type %q", tc.Name, col.Field, col.Type)
+		}
+		typ := col.Type
+		if typ == spider.FieldAny {
+			typ = spider.FieldString
+		}
+		schema = append(schema, spider.Field{Name: col.Field, Type: typ, Required: col.Required, Unit: col.Unit})
+	}
+
+	opts := spider.Options{
+		Name:      tc.Name,
+		WaitTime:  tc.WaitTime,
+		MaxDepth:  5,
+		Weight:    tc.Weight,
+		DependsOn: tc.DependsOn,
+	}
+	if tc.Interval > 0 {
+		opts.Limit = limiter.Multi(
+			rate.NewLimiter(limiter.Per(1, time.Duration(tc.Interval*float64(time.Second))), 1),
+		)
+	}
+
+	urls := tc.URLs
+	return &spider.Task{
+		Options: opts,
+		Rule: spider.RuleTree{
+			Root: func() ([]*spider.Request, error) {
+				roots := make([]*spider.Request, 0, len(urls))
+				for _, u := range urls {
+					roots = append(roots, &spider.Request{
+						URL:      u,
+						Method:   "GET",
+						RuleName: "table",
+					})
+				}
+				return roots, nil
+			},
+			Trunk: map[string]*spider.Rule{
+				"table": {ItemFields: schema, ParseFunc: parser(tc)},
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,105 @@
+package table
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "tasks.json")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadExample(t *testing.T) {
+	tasks, err := Load("../../configs/tasks.example.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 2 {
+		t.Fatalf("got %d tasks", len(tasks))
+	}
+	for _, task := range tasks {
+		rule := task.Rule.Trunk["table"]
+		if rule == nil || len(rule.ItemFields) == 0 {
+			t.Fatalf("task %s: no table rule or schema", task.Name)
+		}
+		roots, err := task.Rule.Root()
+		if err != nil || len(roots) == 0 {
+			t.Fatalf("task %s: roots %v, %v", task.Name, roots, err)
+		}
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "unknown task field",
+			content: `{"tasks":[{"name":"x","urls":["u"],"skiprows":1,"columns":[{"field":"a"}]}]}`,
+			want:    `unknown field "skiprows"`,
+		},
+		{
+			name:    "unknown column field",
+			content: `{"tasks":[{"name":"x","urls":["u"],"columns":[{"field":"a","idx":1}]}]}`,
+			want:    `unknown field "idx"`,
+		},
+		{
+			name:    "misspelled columns",
+			content: `{"tasks":[{"name":"x","urls":["u"],"colums":[{"field":"a"}]}]}`,
+			want:    `unknown field "colums"`,
+		},
+		{
+			name:    "no urls",
+			content: `{"tasks":[{"name":"x","columns":[{"field":"a"}]}]}`,
+			want:    "no urls",
+		},
+		{
+			name:    "no columns",
+			content: `{"tasks":[{"name":"x","urls":["u"]}]}`,
+			want:    "no columns",
+		},
+		{
+			name:    "duplicate field",
+			content: `{"tasks":[{"name":"x","urls":["u"],"columns":[{"field":"a"},{"field":"a","index":1}]}]}`,
+			want:    "duplicate field a",
+		},
+		{
+			name:    "unsupported type",
+			content: `{"tasks":[{"name":"x","urls":["u"],"columns":[{"field":"a","type":"list"}]}]}`,
+			want:    "unsupported type",
+		},
+		{
+			name:    "replace without old",
+			content: `{"tasks":[{"name":"x","urls":["u"],"columns":[{"field":"a","replace":[{"new":"b"}]}]}]}`,
+			want:    "replace without old",
+		},
+		{
+			name:    "replace as map",
+			content: `{"tasks":[{"name":"x","urls":["u"],"columns":[{"field":"a","replace":{"a":"b"}}]}]}`,
+			want:    "cannot unmarshal object",
+		},
+		{
+			name: "duplicate task",
+			content: `{"tasks":[
+				{"name":"x","urls":["u"],"columns":[{"field":"a"}]},
+				{"name":"x","urls":["v"],"columns":[{"field":"a"}]}]}`,
+			want: "duplicate task x",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(writeConfig(t, tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
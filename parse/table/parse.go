@@ -0,0 +1,192 @@
+package table
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/Ysoding/pokemon-wiki-spider/spider"
+	"go.uber.org/zap"
+)
+
+func parser(tc TaskConfig) func(*spider.Context) (spider.ParseResult, error) {
+	want := make(map[int]bool, len(tc.Tables))
+	for _, i := range tc.Tables {
+		want[i] = true
+	}
+
+	return func(ctx *spider.Context) (spider.ParseResult, error) {
+		doc, err := goquery.NewDocumentFromReader(bytes.NewReader(ctx.Body))
+		if err != nil {
+			return spider.ParseResult{}, err
+		}
+
+		tables := doc.Find(tc.Table)
+		if tables.Length() == 0 {
+			// 页面改版后选择器失效, 不当成错误重试, 只提示
+			zap.L().Warn("table selector matched nothing",
+				zap.String("task", tc.Name),
+				zap.String("url", ctx.Req.URL),
+				zap.String("table", tc.Table))
+		}
+
+		var result []interface{}
+		tables.Each(func(i int, table *goquery.Selection) {
+			if len(want) > 0 && !want[i] {
+				return
+			}
+			for n, row := range expandRows(table.Find(tc.Rows)) {
+				if n < tc.SkipRows {
+					continue
+				}
+				if item := rowItem(tc.Columns, row); item != nil {
+					result = append(result, ctx.Output(item))
+				}
+			}
+		})
+
+		return spider.ParseResult{
+			Requesrts: make([]*spider.Request, 0),
+			Items:     result,
+		}, nil
+	}
+}
+
+type span struct {
+	cell *goquery.Selection
+	rows int // 还要占用的行数
+}
+
+// expandRows 按 rowspan 和 colspan 展开表格, 返回每行按列号排列的单元格.
+// 被上面的 rowspan 占用的位置填入同一个单元格, 行比 rowspan 短时中间空出的位置为 nil.
+func expandRows(rows *goquery.Selection) [][]*goquery.Selection {
+	var grid [][]*goquery.Selection
+	pending := make(map[int]*span)
+
+	rows.Each(func(_ int, tr *goquery.Selection) {
+		var row []*goquery.Selection
+		col := 0
+		// take 占用第 col 列上面的 rowspan, 没有时返回 nil
+		take := func() *goquery.Selection {
+			sp, ok := pending[col]
+			if !ok {
+				return nil
+			}
+			if sp.rows--; sp.rows == 0 {
+				delete(pending, col)
+			}
+			return sp.cell
+		}
+		fill := func() {
+			for {
+				cell := take()
+				if cell == nil {
+					return
+				}
+				row = append(row, cell)
+				col++
+			}
+		}
+
+		tr.Children().Filter("td, th").Each(func(_ int, cell *goquery.Selection) {
+			fill()
+			rowspan := attrInt(cell, "rowspan")
+			for i := 0; i < attrInt(cell, "colspan"); i++ {
+				// colspan 和上面的 rowspan 重叠时以当前单元格为准, 上面的 rowspan 照样减一行
+				take()
+				row = append(row, cell)
+				if rowspan > 1 {
+					pending[col] = &span{cell: cell, rows: rowspan - 1}
+				}
+				col++
+			}
+		})
+
+		// 补到最右边的 rowspan, 否则它们不会减少, 会错位到后面的行
+		last := -1
+		for c := range pending {
+			if c > last {
+				last = c
+			}
+		}
+		for col <= last {
+			if cell := take(); cell != nil {
+				row = append(row, cell)
+			} else {
+				row = append(row, nil)
+			}
+			col++
+		}
+
+		grid = append(grid, row)
+	})
+
+	return grid
+}
+
+func attrInt(s *goquery.Selection, name string) int {
+	n, err := strconv.Atoi(strings.TrimSpace(s.AttrOr(name, "1")))
+	if err != nil || n < 1 {
+		return 1
+	}
+	return n
+}
+
+// rowItem 整行都没有取到值时返回 nil, 例如表格中间的分隔行.
+func rowItem(columns []Column, row []*goquery.Selection) map[string]interface{} {
+	item := make(map[string]interface{}, len(columns))
+	empty := true
+	for _, col := range columns {
+		if col.Index >= len(row) || row[col.Index] == nil {
+			continue
+		}
+
+		s := row[col.Index]
+		if col.Selector != "" {
+			s = s.Find(col.Selector).First()
+		}
+
+		var text string
+		if col.Attr != "" {
+			text = s.AttrOr(col.Attr, "")
+		} else {
+			text = s.Text()
+		}
+		text = strings.TrimSpace(text)
+		for _, r := range col.Replace {
+			text = strings.ReplaceAll(text, r.Old, r.New)
+		}
+		if text != "" {
+			empty = false
+		}
+
+		if v, ok := convert(text, col.Type); ok {
+			item[col.Field] = v
+		}
+	}
+
+	if empty {
+		return nil
+	}
+	return item
+}
+
+var (
+	intPattern   = regexp.MustCompile(`-?\d+`)
+	floatPattern = regexp.MustCompile(`-?\d+(\.\d+)?`)
+)
+
+// convert 数字取文本中的第一个数, 例如 "#001" 和 "1.5米", 取不到时不设置这个字段.
+func convert(text string, typ spider.FieldType) (interface{}, bool) {
+	switch typ {
+	case spider.FieldInt:
+		n, err := strconv.Atoi(intPattern.FindString(text))
+		return n, err == nil
+	case spider.FieldFloat:
+		f, err := strconv.ParseFloat(floatPattern.FindString(text), 64)
+		return f, err == nil
+	}
+	return text, true
+}
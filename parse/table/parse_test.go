@@ -0,0 +1,221 @@
+package table
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Ysoding/pokemon-wiki-spider/spider"
+)
+
+func parseHTML(t *testing.T, tc TaskConfig, html string) []map[string]interface{} {
+	t.Helper()
+	tc.Name, tc.URLs = "test", []string{"https://example.com"}
+
+	task, err := Compile(tc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &spider.Request{URL: tc.URLs[0], RuleName: "table", Task: task}
+	result, err := task.Rule.Trunk["table"].ParseFunc(&spider.Context{Body: []byte(html), Req: req})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var items []map[string]interface{}
+	for _, item := range result.Items {
+		items = append(items, item.(*spider.DataCell).Value.(map[string]interface{}))
+	}
+	return items
+}
+
+// textColumns 第 i 列映射到 fields[i].
+func textColumns(fields ...string) []Column {
+	var cols []Column
+	for i, f := range fields {
+		cols = append(cols, Column{Field: f, Index: i})
+	}
+	return cols
+}
+
+type row = map[string]interface{}
+
+func TestParseTable(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		tc   TaskConfig
+		html string
+		want []row
+	}{
+		{
+			name: "skip header",
+			tc:   TaskConfig{SkipRows: 1, Columns: textColumns("a", "b")},
+			html: `<table>
+				<tr><th>A</th><th>B</th></tr>
+				<tr><td> 1 </td><td>2</td></tr>
+				<tr><td>3</td><td>4</td></tr>
+			</table>`,
+			want: []row{{"a": "1", "b": "2"}, {"a": "3", "b": "4"}},
+		},
+		{
+			name: "rowspan",
+			tc:   TaskConfig{Columns: textColumns("a", "b", "c")},
+			html: `<table>
+				<tr><td rowspan="3">x</td><td>1</td><td>2</td></tr>
+				<tr><td>3</td><td>4</td></tr>
+				<tr><td>5</td><td>6</td></tr>
+				<tr><td>y</td><td>7</td><td>8</td></tr>
+			</table>`,
+			want: []row{
+				{"a": "x", "b": "1", "c": "2"},
+				{"a": "x", "b": "3", "c": "4"},
+				{"a": "x", "b": "5", "c": "6"},
+				{"a": "y", "b": "7", "c": "8"},
+			},
+		},
+		{
+			name: "rowspan in middle column",
+			tc:   TaskConfig{Columns: textColumns("a", "b", "c")},
+			html: `<table>
+				<tr><td>1</td><td rowspan="2">x</td><td>2</td></tr>
+				<tr><td>3</td><td>4</td></tr>
+			</table>`,
+			want: []row{{"a": "1", "b": "x", "c": "2"}, {"a": "3", "b": "x", "c": "4"}},
+		},
+		{
+			name: "colspan",
+			tc:   TaskConfig{Columns: textColumns("a", "b", "c")},
+			html: `<table>
+				<tr><td colspan="2">x</td><td>1</td></tr>
+				<tr><td>2</td><td>3</td><td>4</td></tr>
+			</table>`,
+			want: []row{{"a": "x", "b": "x", "c": "1"}, {"a": "2", "b": "3", "c": "4"}},
+		},
+		{
+			name: "rowspan and colspan",
+			tc:   TaskConfig{Columns: textColumns("a", "b", "c")},
+			html: `<table>
+				<tr><td rowspan="2" colspan="2">x</td><td>1</td></tr>
+				<tr><td>2</td></tr>
+				<tr><td>3</td><td>4</td><td>5</td></tr>
+			</table>`,
+			want: []row{
+				{"a": "x", "b": "x", "c": "1"},
+				{"a": "x", "b": "x", "c": "2"},
+				{"a": "3", "b": "4", "c": "5"},
+			},
+		},
+		{
+			// 第二行只有一个单元格, 第三列的 rowspan 要照样占用这一行, 不能错位到第三行
+			name: "short row before pending rowspan",
+			tc:   TaskConfig{Columns: textColumns("a", "b", "c")},
+			html: `<table>
+				<tr><td>1</td><td>2</td><td rowspan="2">x</td></tr>
+				<tr><td>3</td></tr>
+				<tr><td>4</td><td>5</td><td>6</td></tr>
+			</table>`,
+			want: []row{
+				{"a": "1", "b": "2", "c": "x"},
+				{"a": "3", "c": "x"},
+				{"a": "4", "b": "5", "c": "6"},
+			},
+		},
+		{
+			name: "header with rowspan",
+			tc:   TaskConfig{SkipRows: 2, Columns: textColumns("name", "hp", "atk")},
+			html: `<table>
+				<tr><th rowspan="2">名字</th><th colspan="2">种族值</th></tr>
+				<tr><th>HP</th><th>攻击</th></tr>
+				<tr><td>妙蛙种子</td><td>45</td><td>49</td></tr>
+			</table>`,
+			want: []row{{"name": "妙蛙种子", "hp": "45", "atk": "49"}},
+		},
+		{
+			name: "skip rows per table",
+			tc:   TaskConfig{SkipRows: 1, Columns: textColumns("a")},
+			html: `<table><tr><th>A</th></tr><tr><td>1</td></tr></table>
+				<table><tr><th>A</th></tr><tr><td>2</td></tr></table>`,
+			want: []row{{"a": "1"}, {"a": "2"}},
+		},
+		{
+			name: "select tables and rows",
+			tc: TaskConfig{
+				Table:   "#content table",
+				Tables:  []int{1},
+				Rows:    "tbody > tr",
+				Columns: textColumns("a"),
+			},
+			html: `<table><tbody><tr><td>outside</td></tr></tbody></table>
+				<div id="content">
+				<table><tbody><tr><td>first</td></tr></tbody></table>
+				<table><thead><tr><th>head</th></tr></thead><tbody><tr><td>second</td></tr></tbody></table>
+				</div>`,
+			want: []row{{"a": "second"}},
+		},
+		{
+			name: "attr selector replace and types",
+			tc: TaskConfig{Columns: []Column{
+				{
+					Field:    "img",
+					Index:    0,
+					Selector: "img",
+					Attr:     "data-url",
+					Replace:  []Replace{{Old: "//media.52poke.com", New: "https://s1.52poke.wiki"}},
+				},
+				{Field: "index", Index: 1, Type: spider.FieldInt},
+				{Field: "height", Index: 2, Type: spider.FieldFloat},
+			}},
+			html: `<table>
+				<tr><td><span><img data-url="//media.52poke.com/a.png"></span></td><td>#0025</td><td>0.4米</td></tr>
+				<tr><td></td><td>-</td><td>?</td></tr>
+			</table>`,
+			// 取不到数字时不设置这个字段
+			want: []row{
+				{"img": "https://s1.52poke.wiki/a.png", "index": 25, "height": 0.4},
+				{"img": ""},
+			},
+		},
+		{
+			// 第二条规则作用在第一条的结果上, 顺序反过来结果不同
+			name: "replace in order",
+			tc: TaskConfig{Columns: []Column{
+				{Field: "a", Index: 0, Replace: []Replace{
+					{Old: "//media.52poke.com", New: "https://s1.52poke.wiki"},
+					{Old: "https://s1.52poke.wiki/", New: "https://s1.52poke.wiki/img/"},
+				}},
+				{Field: "b", Index: 0, Replace: []Replace{
+					{Old: "https://s1.52poke.wiki/", New: "https://s1.52poke.wiki/img/"},
+					{Old: "//media.52poke.com", New: "https://s1.52poke.wiki"},
+				}},
+			}},
+			html: `<table><tr><td>//media.52poke.com/a.png</td></tr></table>`,
+			want: []row{{"a": "https://s1.52poke.wiki/img/a.png", "b": "https://s1.52poke.wiki/a.png"}},
+		},
+		{
+			name: "empty row skipped",
+			tc:   TaskConfig{Columns: textColumns("a", "b")},
+			html: `<table>
+				<tr><td>1</td><td>2</td></tr>
+				<tr><td> </td><td></td></tr>
+				<tr><td>3</td><td>4</td></tr>
+			</table>`,
+			want: []row{{"a": "1", "b": "2"}, {"a": "3", "b": "4"}},
+		},
+		{
+			name: "no table",
+			tc:   TaskConfig{Table: "#missing", Columns: textColumns("a")},
+			html: `<table><tr><td>1</td></tr></table>`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseHTML(t, tt.tc, tt.html)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d rows %v, want %v", len(got), got, tt.want)
+			}
+			for i := range got {
+				if !reflect.DeepEqual(got[i], tt.want[i]) {
+					t.Errorf("row %d = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
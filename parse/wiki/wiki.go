@@ -0,0 +1,15 @@
+// Package wiki 52poke 页面共用的设置.
+package wiki
+
+import (
+	"github.com/Ysoding/pokemon-wiki-spider/global"
+	"github.com/Ysoding/pokemon-wiki-spider/spider"
+)
+
+// Validator 检查 52poke 条目页面, 由各任务的 Options.Validator 使用, 其他站点的任务仍然只检查状态码.
+var Validator = spider.Validators(
+	spider.StatusValidator,
+	spider.RejectContent(global.WikiMissingPageMarkers...),
+	spider.RetryContent(global.WikiMaintenanceMarkers...),
+	spider.MinLength(global.MinWikiPageLength),
+)
@@ -0,0 +1,120 @@
+package spider
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Fingerprint 计算请求的去重 key, 相同 key 的请求只抓取一次.
+type Fingerprint func(*Request) string
+
+type FingerprintOptions struct {
+	// key 中包含 RuleName, 同一个页面可以按不同规则各解析一次
+	RuleName bool
+	// 路径前缀别名, 例如 "/zh-hans/" -> "/wiki/", 前缀重叠时最长的生效
+	PathVariants []PathVariant
+	// key 中包含请求体和 ContentType, POST 不同参数的请求分开抓取
+	Body bool
+	// key 中包含这些 header, 例如 Accept-Language
+	Headers []string
+}
+
+// PathVariant 把以 Alias 开头的路径改写成以 Canonical 开头.
+type PathVariant struct {
+	Alias     string
+	Canonical string
+}
+
+// MediaWiki 的语言变体路径和 /wiki/ 是同一个页面, 按前缀从长到短排列.
+var WikiPathVariants = []PathVariant{
+	{"/zh-hans/", "/wiki/"},
+	{"/zh-hant/", "/wiki/"},
+	{"/zh-cn/", "/wiki/"},
+	{"/zh-tw/", "/wiki/"},
+	{"/zh-hk/", "/wiki/"},
+	{"/zh/", "/wiki/"},
+}
+
+var DefaultFingerprint = NewFingerprint(FingerprintOptions{
+	RuleName:     true,
+	PathVariants: WikiPathVariants,
+	Body:         true,
+})
+
+func NewFingerprint(opts FingerprintOptions) Fingerprint {
+	opts.PathVariants = sortPathVariants(opts.PathVariants)
+	return func(r *Request) string {
+		method := strings.ToUpper(r.Method)
+		if method == "" {
+			method = "GET"
+		}
+
+		parts := []string{method, CanonicalURL(r.URL, opts.PathVariants)}
+		if opts.RuleName {
+			parts = append(parts, r.RuleName)
+		}
+		if opts.Body && len(r.Body) > 0 {
+			parts = append(parts, r.ContentType, string(r.Body))
+		}
+		for _, h := range opts.Headers {
+			parts = append(parts, h+":"+strings.Join(r.Header.Values(h), ","))
+		}
+
+		block := md5.Sum([]byte(strings.Join(parts, "\n")))
+		return hex.EncodeToString(block[:])
+	}
+}
+
+// CanonicalURL 统一 URL 的写法: 统一百分号编码, host 小写并去掉默认端口,
+// query 参数排序, 去掉 fragment, 按 variants 折叠路径前缀. 解析失败时原样返回.
+// variants 按顺序匹配, 第一个匹配的生效, 前缀重叠时调用方要把长的放在前面.
+func CanonicalURL(rawURL string, variants []PathVariant) string {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return rawURL
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	host := strings.ToLower(u.Hostname())
+	if port := u.Port(); port != "" && !isDefaultPort(u.Scheme, port) {
+		host += ":" + port
+	}
+	u.Host = host
+
+	// Path 已经是解码后的形式, 清空 RawPath 后按统一规则重新编码
+	u.RawPath = ""
+	if u.Path == "" && u.Host != "" {
+		u.Path = "/"
+	}
+	for _, v := range variants {
+		if strings.HasPrefix(u.Path, v.Alias) {
+			u.Path = v.Canonical + strings.TrimPrefix(u.Path, v.Alias)
+			break
+		}
+	}
+
+	if u.RawQuery != "" {
+		// Encode 按 key 排序
+		u.RawQuery = u.Query().Encode()
+	}
+	u.Fragment = ""
+	u.RawFragment = ""
+
+	return u.String()
+}
+
+// sortPathVariants 返回按 Alias 从长到短排序的副本, 不修改调用方的切片.
+func sortPathVariants(variants []PathVariant) []PathVariant {
+	sorted := append([]PathVariant(nil), variants...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return len(sorted[i].Alias) > len(sorted[j].Alias)
+	})
+	return sorted
+}
+
+func isDefaultPort(scheme, port string) bool {
+	return (scheme == "http" && port == "80") || (scheme == "https" && port == "443")
+}
@@ -0,0 +1,187 @@
+package spider
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestCanonicalURL(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"unchanged", "https://wiki.52poke.com/wiki/a", "https://wiki.52poke.com/wiki/a"},
+		{"lower host and scheme", "HTTPS://Wiki.52Poke.COM/wiki/a", "https://wiki.52poke.com/wiki/a"},
+		{"default https port", "https://wiki.52poke.com:443/wiki/a", "https://wiki.52poke.com/wiki/a"},
+		{"default http port", "http://example.com:80/a", "http://example.com/a"},
+		{"other port kept", "https://example.com:8443/a", "https://example.com:8443/a"},
+		{"empty path", "https://example.com", "https://example.com/"},
+		{"fragment", "https://wiki.52poke.com/wiki/a#section", "https://wiki.52poke.com/wiki/a"},
+		{"sorted query", "https://example.com/a?b=2&a=1", "https://example.com/a?a=1&b=2"},
+		{"space in query", "https://example.com/a?q=a%20b", "https://example.com/a?q=a+b"},
+		{"trim spaces", "  https://example.com/a ", "https://example.com/a"},
+		{
+			"unicode path",
+			"https://wiki.52poke.com/wiki/妙蛙种子",
+			"https://wiki.52poke.com/wiki/%E5%A6%99%E8%9B%99%E7%A7%8D%E5%AD%90",
+		},
+		{
+			"escaped path",
+			"https://wiki.52poke.com/wiki/%E5%A6%99%E8%9B%99%E7%A7%8D%E5%AD%90",
+			"https://wiki.52poke.com/wiki/%E5%A6%99%E8%9B%99%E7%A7%8D%E5%AD%90",
+		},
+		{
+			"lowercase escapes",
+			"https://wiki.52poke.com/wiki/%e5%a6%99",
+			"https://wiki.52poke.com/wiki/%E5%A6%99",
+		},
+		{"invalid", "http://[::1", "http://[::1"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CanonicalURL(tt.in, nil); got != tt.want {
+				t.Fatalf("CanonicalURL(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanonicalURLWikiVariants(t *testing.T) {
+	want := "https://wiki.52poke.com/wiki/%E7%9A%AE%E5%8D%A1%E4%B8%98"
+	for _, prefix := range []string{"/wiki/", "/zh/", "/zh-hans/", "/zh-hant/", "/zh-cn/", "/zh-tw/", "/zh-hk/"} {
+		in := "https://wiki.52poke.com" + prefix + "皮卡丘"
+		if got := CanonicalURL(in, WikiPathVariants); got != want {
+			t.Errorf("CanonicalURL(%q) = %q, want %q", in, got, want)
+		}
+	}
+
+	// 只折叠路径开头, 不影响标题里的同名片段
+	in := "https://wiki.52poke.com/wiki/zh-hans/x"
+	if got := CanonicalURL(in, WikiPathVariants); got != in {
+		t.Errorf("CanonicalURL(%q) = %q", in, got)
+	}
+	// 不在 variants 中的语言不折叠
+	in = "https://wiki.52poke.com/ja/x"
+	if got := CanonicalURL(in, WikiPathVariants); got != in {
+		t.Errorf("CanonicalURL(%q) = %q", in, got)
+	}
+}
+
+func TestFingerprintOverlappingVariants(t *testing.T) {
+	// 短前缀在前, 也要按最长的前缀折叠
+	fp := NewFingerprint(FingerprintOptions{PathVariants: []PathVariant{
+		{"/zh/", "/a/"},
+		{"/zh/hans/", "/b/"},
+	}})
+
+	got := &Request{URL: "https://example.com/zh/hans/x"}
+	want := &Request{URL: "https://example.com/b/x"}
+	if fp(got) != fp(want) {
+		t.Fatalf("%s should fold with the longest prefix", got.URL)
+	}
+	if fp(&Request{URL: "https://example.com/zh/x"}) != fp(&Request{URL: "https://example.com/a/x"}) {
+		t.Fatal("shorter prefix should still fold")
+	}
+}
+
+func TestDefaultFingerprint(t *testing.T) {
+	base := &Request{URL: "https://wiki.52poke.com/wiki/皮卡丘", RuleName: "parse"}
+
+	same := []*Request{
+		{URL: "https://wiki.52poke.com/zh-hans/皮卡丘", RuleName: "parse"},
+		{URL: "https://wiki.52poke.com/wiki/%E7%9A%AE%E5%8D%A1%E4%B8%98#概述", RuleName: "parse"},
+		{URL: "https://WIKI.52poke.com:443/wiki/皮卡丘", Method: "get", RuleName: "parse"},
+	}
+	for _, r := range same {
+		if DefaultFingerprint(r) != DefaultFingerprint(base) {
+			t.Errorf("%s %s should have the same fingerprint as %s", r.Method, r.URL, base.URL)
+		}
+	}
+
+	different := []*Request{
+		{URL: base.URL, RuleName: "list"},
+		{URL: base.URL, RuleName: "parse", Method: "POST"},
+		{URL: "https://wiki.52poke.com/wiki/雷丘", RuleName: "parse"},
+	}
+	for _, r := range different {
+		if DefaultFingerprint(r) == DefaultFingerprint(base) {
+			t.Errorf("%s %s %s should differ from %s", r.Method, r.URL, r.RuleName, base.URL)
+		}
+	}
+}
+
+func TestFingerprintOptions(t *testing.T) {
+	fp := NewFingerprint(FingerprintOptions{})
+
+	list := &Request{URL: "https://wiki.52poke.com/wiki/a", RuleName: "list"}
+	detail := &Request{URL: "https://wiki.52poke.com/wiki/a", RuleName: "detail"}
+	if fp(list) != fp(detail) {
+		t.Fatal("RuleName should not be part of the fingerprint")
+	}
+
+	// 没有 PathVariants 时不折叠语言变体
+	variant := &Request{URL: "https://wiki.52poke.com/zh-hans/a"}
+	if fp(list) == fp(variant) {
+		t.Fatal("path variants should only fold when configured")
+	}
+}
+
+func TestFingerprintBodyAndHeaders(t *testing.T) {
+	base := &Request{
+		URL:         "https://example.com/api.php",
+		Method:      "POST",
+		Body:        []byte("page=1"),
+		ContentType: ContentTypeForm,
+		Header:      http.Header{"Accept-Language": {"zh-CN"}},
+	}
+	with := func(f func(r *Request)) *Request {
+		r := *base
+		r.Header = base.Header.Clone()
+		f(&r)
+		return &r
+	}
+
+	fp := NewFingerprint(FingerprintOptions{Body: true, Headers: []string{"Accept-Language"}})
+	for _, tt := range []struct {
+		name string
+		req  *Request
+		same bool
+	}{
+		{"same", with(func(r *Request) {}), true},
+		{"lower case method", with(func(r *Request) { r.Method = "post" }), true},
+		{"other header", with(func(r *Request) { r.Header.Set("User-Agent", "x") }), true},
+		{"method", with(func(r *Request) { r.Method = "PUT" }), false},
+		{"body", with(func(r *Request) { r.Body = []byte("page=2") }), false},
+		{"content type", with(func(r *Request) { r.ContentType = ContentTypeJSON }), false},
+		{"listed header", with(func(r *Request) { r.Header.Set("Accept-Language", "zh-TW") }), false},
+		{"missing header", with(func(r *Request) { r.Header = nil }), false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fp(tt.req) == fp(base); got != tt.same {
+				t.Fatalf("same fingerprint = %v, want %v", got, tt.same)
+			}
+		})
+	}
+
+	// 不开启 Body 时只按 method 和 URL 区分
+	fp = NewFingerprint(FingerprintOptions{})
+	if fp(with(func(r *Request) { r.Body = []byte("page=2") })) != fp(base) {
+		t.Fatal("body should not be part of the fingerprint")
+	}
+	if fp(with(func(r *Request) { r.Header.Set("Accept-Language", "zh-TW") })) != fp(base) {
+		t.Fatal("headers should not be part of the fingerprint")
+	}
+}
+
+func TestRequestUnique(t *testing.T) {
+	task := &Task{}
+	r := &Request{URL: "https://example.com/a", Task: task}
+	if r.Unique() != DefaultFingerprint(r) {
+		t.Fatal("Unique should use DefaultFingerprint")
+	}
+
+	task.Fingerprint = func(r *Request) string { return "fixed" }
+	if r.Unique() != "fixed" {
+		t.Fatal("Unique should use the task fingerprint")
+	}
+}
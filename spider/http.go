@@ -0,0 +1,90 @@
+package spider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+const (
+	ContentTypeForm = "application/x-www-form-urlencoded"
+	ContentTypeJSON = "application/json"
+)
+
+func (r *Request) SetHeader(key, value string) {
+	if r.Header == nil {
+		r.Header = make(http.Header)
+	}
+	r.Header.Set(key, value)
+}
+
+// SetQuery 设置 URL 的 query 参数, 已有的同名参数会被替换.
+func (r *Request) SetQuery(key, value string) error {
+	return r.updateQuery(func(q url.Values) { q.Set(key, value) })
+}
+
+// AddQuery 追加 URL 的 query 参数.
+func (r *Request) AddQuery(key, value string) error {
+	return r.updateQuery(func(q url.Values) { q.Add(key, value) })
+}
+
+func (r *Request) updateQuery(f func(url.Values)) error {
+	u, err := url.Parse(r.URL)
+	if err != nil {
+		return err
+	}
+	q := u.Query()
+	f(q)
+	u.RawQuery = q.Encode()
+	r.URL = u.String()
+	return nil
+}
+
+// SetForm 以表单 POST 提交, 例如 MediaWiki api.php 或者搜索表单.
+func (r *Request) SetForm(form url.Values) {
+	r.Method = http.MethodPost
+	r.ContentType = ContentTypeForm
+	r.Body = []byte(form.Encode())
+}
+
+// SetJSON 以 JSON POST 提交.
+func (r *Request) SetJSON(v interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	r.Method = http.MethodPost
+	r.ContentType = ContentTypeJSON
+	r.Body = body
+	return nil
+}
+
+// HTTPRequest 生成 net/http 的请求, Method 为空时使用 GET.
+func (r *Request) HTTPRequest(ctx context.Context) (*http.Request, error) {
+	method := r.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	var body io.Reader
+	if len(r.Body) > 0 {
+		body = bytes.NewReader(r.Body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, r.URL, body)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range r.Header {
+		req.Header[k] = append([]string(nil), v...)
+	}
+	if r.ContentType != "" {
+		req.Header.Set("Content-Type", r.ContentType)
+	}
+
+	return req, nil
+}
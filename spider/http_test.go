@@ -0,0 +1,179 @@
+package spider
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestSetHeader(t *testing.T) {
+	r := &Request{}
+	r.SetHeader("accept-language", "zh-CN")
+	r.SetHeader("Accept-Language", "zh-TW")
+	if got := r.Header.Values("Accept-Language"); len(got) != 1 || got[0] != "zh-TW" {
+		t.Fatalf("Accept-Language = %v, want [zh-TW]", got)
+	}
+}
+
+func TestQuery(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		url  string
+		set  func(r *Request) error
+		want string
+	}{
+		{
+			"set new",
+			"https://example.com/api.php",
+			func(r *Request) error { return r.SetQuery("action", "parse") },
+			"https://example.com/api.php?action=parse",
+		},
+		{
+			"set replaces",
+			"https://example.com/api.php?page=1&page=2",
+			func(r *Request) error { return r.SetQuery("page", "3") },
+			"https://example.com/api.php?page=3",
+		},
+		{
+			"add keeps",
+			"https://example.com/api.php?page=1",
+			func(r *Request) error { return r.AddQuery("page", "2") },
+			"https://example.com/api.php?page=1&page=2",
+		},
+		{
+			"escaped value",
+			"https://example.com/api.php",
+			func(r *Request) error { return r.SetQuery("title", "皮卡丘 &") },
+			"https://example.com/api.php?title=%E7%9A%AE%E5%8D%A1%E4%B8%98+%26",
+		},
+		{
+			"keeps fragment",
+			"https://example.com/a#top",
+			func(r *Request) error { return r.AddQuery("b", "1") },
+			"https://example.com/a?b=1#top",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Request{URL: tt.url}
+			if err := tt.set(r); err != nil {
+				t.Fatal(err)
+			}
+			if r.URL != tt.want {
+				t.Fatalf("URL = %q, want %q", r.URL, tt.want)
+			}
+		})
+	}
+
+	// URL 解析失败时返回 error, 不修改 URL
+	r := &Request{URL: "http://[::1"}
+	if err := r.SetQuery("a", "1"); err == nil || r.URL != "http://[::1" {
+		t.Fatalf("err = %v, URL = %q", err, r.URL)
+	}
+}
+
+func TestSetBody(t *testing.T) {
+	form := &Request{}
+	form.SetForm(url.Values{"action": {"query"}, "titles": {"皮卡丘"}})
+
+	body := &Request{}
+	if err := body.SetJSON(map[string]int{"page": 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name        string
+		req         *Request
+		body        string
+		contentType string
+	}{
+		{"form", form, "action=query&titles=%E7%9A%AE%E5%8D%A1%E4%B8%98", ContentTypeForm},
+		{"json", body, `{"page":1}`, ContentTypeJSON},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.req.Method != http.MethodPost {
+				t.Errorf("Method = %q, want POST", tt.req.Method)
+			}
+			if string(tt.req.Body) != tt.body {
+				t.Errorf("Body = %q, want %q", tt.req.Body, tt.body)
+			}
+			if tt.req.ContentType != tt.contentType {
+				t.Errorf("ContentType = %q, want %q", tt.req.ContentType, tt.contentType)
+			}
+		})
+	}
+
+	// 无法编码时不修改请求
+	r := &Request{}
+	if err := r.SetJSON(func() {}); err == nil || r.Method != "" || r.Body != nil {
+		t.Fatalf("err = %v, request = %+v", err, r)
+	}
+}
+
+func TestHTTPRequest(t *testing.T) {
+	for _, tt := range []struct {
+		name        string
+		req         *Request
+		method      string
+		body        string
+		contentType string
+	}{
+		{"default get", &Request{URL: "https://example.com/a"}, http.MethodGet, "", ""},
+		{
+			"post body",
+			&Request{URL: "https://example.com/a", Method: http.MethodPost, Body: []byte("a=1"), ContentType: ContentTypeForm},
+			http.MethodPost, "a=1", ContentTypeForm,
+		},
+		{
+			"content type overrides header",
+			&Request{
+				URL:         "https://example.com/a",
+				Method:      http.MethodPut,
+				Header:      http.Header{"Content-Type": {"text/plain"}},
+				Body:        []byte("{}"),
+				ContentType: ContentTypeJSON,
+			},
+			http.MethodPut, "{}", ContentTypeJSON,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := tt.req.HTTPRequest(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if req.Method != tt.method {
+				t.Errorf("Method = %q, want %q", req.Method, tt.method)
+			}
+			if got := req.Header.Get("Content-Type"); got != tt.contentType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.contentType)
+			}
+			var body string
+			if req.Body != nil {
+				b, err := io.ReadAll(req.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				body = string(b)
+			}
+			if body != tt.body {
+				t.Errorf("body = %q, want %q", body, tt.body)
+			}
+		})
+	}
+
+	// 修改生成的请求不影响原请求的 header
+	r := &Request{URL: "https://example.com/a", Header: http.Header{"Accept": {"text/html"}}}
+	req, err := r.HTTPRequest(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Accept", "application/json")
+	if got := r.Header.Values("Accept"); len(got) != 1 {
+		t.Fatalf("request header changed: %v", got)
+	}
+
+	if _, err := (&Request{URL: "http://[::1"}).HTTPRequest(context.Background()); err == nil {
+		t.Fatal("want error for invalid URL")
+	}
+}
@@ -0,0 +1,79 @@
+package spider
+
+import (
+	"reflect"
+	"strings"
+
+	"golang.org/x/text/width"
+)
+
+// ItemProcessor 在数据交给 Storage 之前处理一条数据, 可以修改, 补充或者校验.
+// 返回的数据替换原来的数据: 返回空表示丢弃, 返回多条可以额外产出数据.
+type ItemProcessor func(item *DataCell) ([]*DataCell, error)
+
+// MapStrings 对 fields 中的字符串字段调用 f, fields 为空时处理所有字符串字段.
+func MapStrings(f func(string) string, fields ...string) ItemProcessor {
+	return func(item *DataCell) ([]*DataCell, error) {
+		names := fields
+		if len(names) == 0 {
+			all, err := item.Fields()
+			if err != nil {
+				return nil, err
+			}
+			for k := range all {
+				names = append(names, k)
+			}
+		}
+
+		for _, name := range names {
+			if m, ok := item.Value.(map[string]interface{}); ok {
+				if s, ok := m[name].(string); ok {
+					m[name] = f(s)
+				}
+			} else if fv, ok := item.field(name); ok && fv.Kind() == reflect.String {
+				fv.SetString(f(fv.String()))
+			}
+			if s, ok := item.Extra[name].(string); ok {
+				item.Extra[name] = f(s)
+			}
+		}
+		return []*DataCell{item}, nil
+	}
+}
+
+// NormalizeWidth 全角字母数字和符号转为半角.
+func NormalizeWidth(fields ...string) ItemProcessor {
+	return MapStrings(width.Fold.String, fields...)
+}
+
+// TrimNewlines 去掉换行和首尾空白, 例如招式的 Effect.
+func TrimNewlines(fields ...string) ItemProcessor {
+	return MapStrings(func(s string) string {
+		return strings.TrimSpace(strings.ReplaceAll(s, "\n", ""))
+	}, fields...)
+}
+
+// SetField 设置计算出来的字段, Value 中有同名同类型的字段时直接修改, 否则放进 Extra.
+func SetField(name string, f func(fields map[string]interface{}) interface{}) ItemProcessor {
+	return func(item *DataCell) ([]*DataCell, error) {
+		fields, err := item.Fields()
+		if err != nil {
+			return nil, err
+		}
+		v := f(fields)
+
+		if m, ok := item.Value.(map[string]interface{}); ok {
+			m[name] = v
+			return []*DataCell{item}, nil
+		}
+		if fv, ok := item.field(name); ok && v != nil && reflect.TypeOf(v).AssignableTo(fv.Type()) {
+			fv.Set(reflect.ValueOf(v))
+			return []*DataCell{item}, nil
+		}
+		if item.Extra == nil {
+			item.Extra = make(map[string]interface{})
+		}
+		item.Extra[name] = v
+		return []*DataCell{item}, nil
+	}
+}
@@ -0,0 +1,63 @@
+package spider
+
+import (
+	"testing"
+)
+
+type itemData struct {
+	NameZh string
+	Effect string
+	Index  int
+}
+
+func process(t *testing.T, p ItemProcessor, d *DataCell) *DataCell {
+	t.Helper()
+	out, err := p(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != 1 {
+		t.Fatalf("got %d items", len(out))
+	}
+	return out[0]
+}
+
+func TestTrimNewlines(t *testing.T) {
+	d := process(t, TrimNewlines("Effect"), &DataCell{Value: &itemData{NameZh: " a\n", Effect: "\n降低\n对手\n"}})
+	v := d.Value.(*itemData)
+	if v.Effect != "降低对手" {
+		t.Fatalf("Effect = %q", v.Effect)
+	}
+	if v.NameZh != " a\n" {
+		t.Fatalf("NameZh changed to %q", v.NameZh)
+	}
+}
+
+func TestNormalizeWidth(t *testing.T) {
+	// 结构体值会换成可修改的副本
+	d := process(t, NormalizeWidth(), &DataCell{Value: itemData{NameZh: "Ｚ纯晶１"}})
+	if v := d.Value.(*itemData); v.NameZh != "Z纯晶1" {
+		t.Fatalf("NameZh = %q", v.NameZh)
+	}
+
+	d = process(t, NormalizeWidth(), &DataCell{Value: map[string]interface{}{"NameZh": "ＡＢ", "Index": 1}})
+	if v := d.Value.(map[string]interface{}); v["NameZh"] != "AB" || v["Index"] != 1 {
+		t.Fatalf("Value = %v", v)
+	}
+}
+
+func TestSetField(t *testing.T) {
+	double := func(fields map[string]interface{}) interface{} {
+		return fields["Index"].(int) * 2
+	}
+
+	d := process(t, SetField("Index", double), &DataCell{Value: &itemData{Index: 2}})
+	if v := d.Value.(*itemData); v.Index != 4 || d.Extra != nil {
+		t.Fatalf("Value = %+v, Extra = %v", v, d.Extra)
+	}
+
+	d = process(t, SetField("Double", double), &DataCell{Value: &itemData{Index: 2}})
+	if d.Extra["Double"] != 4 {
+		t.Fatalf("Extra = %v", d.Extra)
+	}
+}
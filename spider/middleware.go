@@ -0,0 +1,20 @@
+package spider
+
+import "context"
+
+// Middleware 抓取和解析过程中的钩子, 不需要的钩子留空.
+// engine 的中间件先于任务的中间件调用, 同一层按注册顺序调用.
+type Middleware struct {
+	Name string
+	// 发起请求前调用, 可以修改请求, 例如加 header.
+	// 返回 error 时丢弃请求; 返回非 nil 的 Response 时不再抓取, 例如命中缓存.
+	OnRequest func(ctx context.Context, req *Request) (*Response, error)
+	// 拿到响应后, Validator 之前调用, 返回 error 时按抓取失败处理
+	OnResponse func(req *Request, resp *Response) error
+	// 抓取失败, 响应没有通过校验或者解析失败时调用, 包括会重试的失败
+	OnError func(req *Request, err error)
+	// 解析出的每条数据保存前调用, 返回 nil 时丢弃
+	OnItem func(req *Request, item interface{}) interface{}
+	// ParseFunc 返回 error 时调用, 在 OnError 之前
+	OnParseError func(req *Request, err error)
+}
@@ -1,6 +1,9 @@
 package spider
 
 import (
+	"time"
+
+	"github.com/Ysoding/pokemon-wiki-spider/dedup"
 	"github.com/Ysoding/pokemon-wiki-spider/limiter"
 	"go.uber.org/zap"
 )
@@ -12,11 +15,30 @@ type Options struct {
 	URL      string
 	Cookie   string
 	WaitTime int64 // second
-	MaxDepth int64
-	logger   *zap.Logger
-	Fetcher  Fetcher
-	Storage  Storage
-	Limit    limiter.RateLimiter
+	MaxDepth int64 // Root 返回的请求深度为 0, <= 0 不限制
+	Weight   int   // FairSchedule 中的调度权重, <= 0 按 1 计算
+	// 依赖的任务名, 这些任务完成并 flush storage 之后才执行 Root
+	DependsOn []string
+	logger    *zap.Logger
+	Fetcher   Fetcher
+	Storage   Storage
+	Limit     limiter.RateLimiter
+	// 为 nil 时使用 engine 的重试策略
+	RetryPolicy *RetryPolicy
+	// 为 nil 时使用 engine 的 Validator
+	Validator Validator
+	// 为 nil 时使用 engine 的去重记录
+	Dedup dedup.Store
+	// 为 nil 时使用 DefaultFingerprint
+	Fingerprint Fingerprint
+	// 抓取预算, 任意一项用完后任务不再发起请求并直接结束, <= 0 不限制
+	MaxRequests int64
+	MaxItems    int64
+	MaxDuration time.Duration
+	// 在 engine 的中间件之后调用
+	Middlewares []Middleware
+	// 数据保存前依次执行, 在 engine 的处理器之后
+	Processors []ItemProcessor
 }
 
 var defaultOptions = Options{
@@ -55,6 +77,18 @@ func WithWaitTime(waitTime int64) Option {
 	}
 }
 
+func WithWeight(weight int) Option {
+	return func(opts *Options) {
+		opts.Weight = weight
+	}
+}
+
+func WithDependsOn(names ...string) Option {
+	return func(opts *Options) {
+		opts.DependsOn = names
+	}
+}
+
 func WithFetcher(fetcher Fetcher) Option {
 	return func(opts *Options) {
 		opts.Fetcher = fetcher
@@ -72,3 +106,57 @@ func WithLimit(limiter limiter.RateLimiter) Option {
 		opts.Limit = limiter
 	}
 }
+
+func WithRetryPolicy(policy *RetryPolicy) Option {
+	return func(opts *Options) {
+		opts.RetryPolicy = policy
+	}
+}
+
+func WithValidator(validator Validator) Option {
+	return func(opts *Options) {
+		opts.Validator = validator
+	}
+}
+
+func WithDedup(store dedup.Store) Option {
+	return func(opts *Options) {
+		opts.Dedup = store
+	}
+}
+
+func WithFingerprint(f Fingerprint) Option {
+	return func(opts *Options) {
+		opts.Fingerprint = f
+	}
+}
+
+func WithMaxRequests(n int64) Option {
+	return func(opts *Options) {
+		opts.MaxRequests = n
+	}
+}
+
+func WithMaxItems(n int64) Option {
+	return func(opts *Options) {
+		opts.MaxItems = n
+	}
+}
+
+func WithMaxDuration(d time.Duration) Option {
+	return func(opts *Options) {
+		opts.MaxDuration = d
+	}
+}
+
+func WithMiddleware(mws ...Middleware) Option {
+	return func(opts *Options) {
+		opts.Middlewares = append(opts.Middlewares, mws...)
+	}
+}
+
+func WithProcessors(ps ...ItemProcessor) Option {
+	return func(opts *Options) {
+		opts.Processors = append(opts.Processors, ps...)
+	}
+}
@@ -11,6 +11,9 @@ type RuleTree struct {
 }
 
 type Rule struct {
-	ItemFields []string
+	// 产出数据的结构, engine 按它校验每条数据
+	ItemFields Schema
 	ParseFunc  func(*Context) (ParseResult, error)
+	// 为 nil 时使用 Task 的 Validator
+	Validator Validator
 }
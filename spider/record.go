@@ -0,0 +1,53 @@
+package spider
+
+import "net/http"
+
+// Record 请求的可序列化形式, Task 只保存名字, 恢复时按名字重新关联.
+type Record struct {
+	Task        string
+	URL         string
+	Method      string
+	Header      http.Header `json:",omitempty"`
+	Body        []byte      `json:",omitempty"`
+	ContentType string      `json:",omitempty"`
+	RuleName    string
+	Depth       int64
+	Priority    int
+	Attempt     int
+	TempData    *TempData `json:",omitempty"`
+}
+
+func (r *Request) Record() Record {
+	rec := Record{
+		URL:         r.URL,
+		Method:      r.Method,
+		Header:      r.Header,
+		Body:        r.Body,
+		ContentType: r.ContentType,
+		RuleName:    r.RuleName,
+		Depth:       r.Depth,
+		Priority:    r.Priority,
+		Attempt:     r.Attempt,
+		TempData:    r.TempData,
+	}
+	if r.Task != nil {
+		rec.Task = r.Task.Name
+	}
+	return rec
+}
+
+func (rec Record) Request(task *Task) *Request {
+	return &Request{
+		Task:        task,
+		URL:         rec.URL,
+		Method:      rec.Method,
+		Header:      rec.Header,
+		Body:        rec.Body,
+		ContentType: rec.ContentType,
+		RuleName:    rec.RuleName,
+		Depth:       rec.Depth,
+		Priority:    rec.Priority,
+		Attempt:     rec.Attempt,
+		TempData:    rec.TempData,
+	}
+}
@@ -0,0 +1,50 @@
+package spider
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]*Task)
+)
+
+// Register 按 Options.Name 登记任务, 一般在解析包的 init 里调用, 重名会 panic.
+func Register(tasks ...*Task) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	for _, task := range tasks {
+		if task.Name == "" {
+			panic("spider: Register task with empty name")
+		}
+		if _, dup := registry[task.Name]; dup {
+			panic(fmt.Sprintf("spider: Register called twice for task %q", task.Name))
+		}
+		registry[task.Name] = task
+	}
+}
+
+// LookupTask 找不到返回 nil.
+func LookupTask(name string) *Task {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return registry[name]
+}
+
+// RegisteredTasks 按名字排序.
+func RegisteredTasks() []*Task {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	tasks := make([]*Task, 0, len(registry))
+	for _, task := range registry {
+		tasks = append(tasks, task)
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].Name < tasks[j].Name
+	})
+	return tasks
+}
@@ -1,54 +1,87 @@
 package spider
 
 import (
-	"crypto/md5"
-	"encoding/hex"
+	"context"
 	"errors"
 	"math/rand"
+	"net/http"
 	"time"
 )
 
 type Request struct {
-	Task     *Task
-	URL      string
-	Method   string
-	RuleName string
-	Depth    int64
-	TempData *TempData
+	Task        *Task
+	URL         string
+	Method      string // 为空时 GET
+	Header      http.Header
+	Body        []byte
+	ContentType string
+	RuleName    string
+	Depth       int64
+	Priority    int // 越大越先被调度
+	Attempt     int // 已经尝试抓取的次数
+	TempData    *TempData
 }
 
 type Context struct {
 	Body []byte
+	Resp *Response
 	Req  *Request
 }
 
+// Output 包装解析出的数据, data 通常是结构体指针.
 func (c *Context) Output(data interface{}) *DataCell {
-	res := &DataCell{
-		Task: c.Req.Task,
+	fetchedAt := time.Now()
+	if c.Resp != nil && !c.Resp.FetchedAt.IsZero() {
+		fetchedAt = c.Resp.FetchedAt
 	}
 
-	res.Data = make(map[string]interface{})
-	res.Data["Task"] = c.Req.Task.Name
-	res.Data["Data"] = data
-	res.Data["Time"] = time.Now().Format("2006-01-02 15:04:05")
-
-	return res
+	return &DataCell{
+		Task:  c.Req.Task,
+		Value: data,
+		Meta: Meta{
+			Task:      c.Req.Task.Name,
+			URL:       c.Req.URL,
+			Rule:      c.Req.RuleName,
+			FetchedAt: fetchedAt,
+		},
+	}
 }
 
-func (r *Request) Fetch() ([]byte, error) {
+// Wait 请求前按任务的 WaitTime 随机等待.
+func (r *Request) Wait(ctx context.Context) error {
+	if r.Task.WaitTime <= 0 {
+		return nil
+	}
+
 	sleepTime := rand.Int63n(r.Task.WaitTime * 1000)
-	time.Sleep(time.Duration(sleepTime) * time.Millisecond)
+	timer := time.NewTimer(time.Duration(sleepTime) * time.Millisecond)
+	defer timer.Stop()
 
-	return r.Task.Fetcher.Get(r)
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
+func (r *Request) Fetch(ctx context.Context) (*Response, error) {
+	if err := r.Wait(ctx); err != nil {
+		return nil, err
+	}
+	return r.Task.Fetcher.Get(ctx, r)
+}
+
+// Unique 请求的去重 key, 任务没有设置 Fingerprint 时使用 DefaultFingerprint.
 func (r *Request) Unique() string {
-	block := md5.Sum([]byte(r.URL + r.Method))
-	return hex.EncodeToString(block[:])
+	if r.Task != nil && r.Task.Fingerprint != nil {
+		return r.Task.Fingerprint(r)
+	}
+	return DefaultFingerprint(r)
 }
 
 func (r *Request) Check() error {
-	if r.Depth > r.Task.MaxDepth {
+	if r.Task.MaxDepth > 0 && r.Depth > r.Task.MaxDepth {
 		return errors.New("max depth limit reached")
 	}
 	return nil
@@ -0,0 +1,131 @@
+package spider
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// StatusError 非预期的 HTTP 状态码.
+type StatusError struct {
+	Code int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("error status code:%d", e.Code)
+}
+
+// PermanentError 包装的错误不会重试.
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+// RetryableError 包装的错误在次数用完前总是重试, 不再看 RetryableStatus 和 Retryable,
+// 例如 Validator 判定为 Retry 的响应.
+type RetryableError struct {
+	Err error
+}
+
+func (e *RetryableError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RetryableError) Unwrap() error {
+	return e.Err
+}
+
+type RetryPolicy struct {
+	MaxAttempts int // 最多尝试次数, 包括第一次
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	Jitter      float64 // 退避时间随机浮动的比例, 0 ~ 1
+	// 可重试的状态码, 只对 StatusError 生效
+	RetryableStatus []int
+	// 自定义哪些错误可以重试, 为 nil 时除了状态码不在 RetryableStatus 中的 StatusError 都重试
+	Retryable func(err error) bool
+}
+
+// DefaultRetryPolicy 只作为模板, 使用时通过 Clone 复制, 不要直接修改.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:     3,
+	BaseDelay:       2 * time.Second,
+	MaxDelay:        time.Minute,
+	Jitter:          0.2,
+	RetryableStatus: []int{408, 429, 500, 502, 503, 504},
+}
+
+// Clone 返回副本, 修改副本不影响其他任务, p 为 nil 时返回 nil.
+func (p *RetryPolicy) Clone() *RetryPolicy {
+	if p == nil {
+		return nil
+	}
+	c := *p
+	c.RetryableStatus = append([]int(nil), p.RetryableStatus...)
+	return &c
+}
+
+// ShouldRetry attempt 为已经尝试的次数.
+func (p *RetryPolicy) ShouldRetry(err error, attempt int) bool {
+	if attempt >= p.MaxAttempts {
+		return false
+	}
+
+	var permanent *PermanentError
+	if errors.As(err, &permanent) {
+		return false
+	}
+	var retryable *RetryableError
+	if errors.As(err, &retryable) {
+		return true
+	}
+
+	if p.Retryable != nil {
+		return p.Retryable(err)
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		for _, code := range p.RetryableStatus {
+			if code == statusErr.Code {
+				return true
+			}
+		}
+		return false
+	}
+
+	return true
+}
+
+// Backoff 第 attempt 次失败后需要等待的时间, 指数增长并加上随机抖动, 不超过 MaxDelay.
+func (p *RetryPolicy) Backoff(attempt int) time.Duration {
+	if p.BaseDelay <= 0 || attempt < 1 {
+		return 0
+	}
+
+	d := p.BaseDelay
+	for i := 1; i < attempt && (p.MaxDelay <= 0 || d < p.MaxDelay); i++ {
+		d *= 2
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+
+	if p.Jitter > 0 {
+		delta := float64(d) * p.Jitter
+		d += time.Duration(delta * (2*rand.Float64() - 1))
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+
+	return d
+}
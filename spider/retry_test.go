@@ -0,0 +1,82 @@
+package spider
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		policy  RetryPolicy
+		attempt int
+		want    time.Duration
+	}{
+		{"no base delay", RetryPolicy{MaxDelay: time.Second}, 3, 0},
+		{"attempt 0", RetryPolicy{BaseDelay: time.Second}, 0, 0},
+		{"first", RetryPolicy{BaseDelay: time.Second, MaxDelay: time.Minute}, 1, time.Second},
+		{"exponential", RetryPolicy{BaseDelay: time.Second, MaxDelay: time.Minute}, 4, 8 * time.Second},
+		{"capped", RetryPolicy{BaseDelay: time.Second, MaxDelay: 5 * time.Second}, 4, 5 * time.Second},
+		{"base above max", RetryPolicy{BaseDelay: time.Minute, MaxDelay: time.Second}, 1, time.Second},
+		{"no max", RetryPolicy{BaseDelay: time.Second}, 11, 1024 * time.Second},
+		{"many attempts", RetryPolicy{BaseDelay: time.Second, MaxDelay: time.Minute}, 1000, time.Minute},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.Backoff(tt.attempt); got != tt.want {
+				t.Fatalf("Backoff(%d) = %v, want %v", tt.attempt, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackoffJitterCapped(t *testing.T) {
+	p := RetryPolicy{BaseDelay: time.Second, MaxDelay: 4 * time.Second, Jitter: 0.5}
+	for i := 0; i < 100; i++ {
+		if d := p.Backoff(1); d < 500*time.Millisecond || d > 1500*time.Millisecond {
+			t.Fatalf("Backoff(1) = %v out of jitter range", d)
+		}
+		if d := p.Backoff(5); d > p.MaxDelay {
+			t.Fatalf("Backoff(5) = %v above MaxDelay", d)
+		}
+	}
+}
+
+func TestShouldRetry(t *testing.T) {
+	p := DefaultRetryPolicy.Clone()
+	network := errors.New("connection reset")
+
+	for _, tt := range []struct {
+		name    string
+		err     error
+		attempt int
+		want    bool
+	}{
+		{"network error", network, 1, true},
+		{"attempts used up", network, p.MaxAttempts, false},
+		{"retryable status", &StatusError{Code: 503}, 1, true},
+		{"other status", &StatusError{Code: 404}, 1, false},
+		{"permanent", &PermanentError{Err: network}, 1, false},
+		{"validator retry", &RetryableError{Err: &StatusError{Code: 501}}, 1, true},
+		{"validator retry used up", &RetryableError{Err: &StatusError{Code: 501}}, p.MaxAttempts, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.ShouldRetry(tt.err, tt.attempt); got != tt.want {
+				t.Fatalf("ShouldRetry = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyClone(t *testing.T) {
+	if (*RetryPolicy)(nil).Clone() != nil {
+		t.Fatal("Clone of nil policy should be nil")
+	}
+
+	p := DefaultRetryPolicy.Clone()
+	p.MaxAttempts = 10
+	p.RetryableStatus[0] = 404
+	if DefaultRetryPolicy.MaxAttempts == 10 || DefaultRetryPolicy.RetryableStatus[0] == 404 {
+		t.Fatal("changing a clone modified DefaultRetryPolicy")
+	}
+}
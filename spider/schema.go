@@ -0,0 +1,119 @@
+package spider
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+type FieldType string
+
+const (
+	FieldAny    FieldType = ""
+	FieldString FieldType = "string"
+	FieldInt    FieldType = "int"
+	FieldFloat  FieldType = "float"
+	FieldBool   FieldType = "bool"
+	FieldList   FieldType = "list"   // 切片
+	FieldObject FieldType = "object" // 结构体或 map
+)
+
+// Field 规则产出数据的一个字段.
+type Field struct {
+	Name     string
+	Type     FieldType
+	Required bool   // 不能缺失也不能是零值, 例如页面改版后变成空字符串
+	Unit     string // 单位, 只用于表头
+}
+
+// Schema 规则产出数据的结构, 为空时不校验.
+type Schema []Field
+
+// Validate 返回 fields 违反 Schema 的地方, 没有声明的字段不检查.
+func (s Schema) Validate(fields map[string]interface{}) []error {
+	var errs []error
+	for _, f := range s {
+		v, ok := fields[f.Name]
+		if !ok || v == nil {
+			if f.Required {
+				errs = append(errs, fmt.Errorf("field %s: missing", f.Name))
+			}
+			continue
+		}
+
+		rv := reflect.ValueOf(v)
+		if f.Type != FieldAny && !f.Type.match(rv.Kind()) {
+			errs = append(errs, fmt.Errorf("field %s: want %s, got %T", f.Name, f.Type, v))
+			continue
+		}
+		if f.Required && rv.IsZero() {
+			errs = append(errs, fmt.Errorf("field %s: empty", f.Name))
+		}
+	}
+	return errs
+}
+
+func (t FieldType) match(k reflect.Kind) bool {
+	switch t {
+	case FieldString:
+		return k == reflect.String
+	case FieldInt:
+		return k >= reflect.Int && k <= reflect.Uint64
+	case FieldFloat:
+		return k == reflect.Float32 || k == reflect.Float64
+	case FieldBool:
+		return k == reflect.Bool
+	case FieldList:
+		return k == reflect.Slice || k == reflect.Array
+	case FieldObject:
+		return k == reflect.Struct || k == reflect.Map || k == reflect.Ptr
+	}
+	return true
+}
+
+// Header CSV 表头, 有单位的字段写成 "Height(m)".
+func (s Schema) Header() []string {
+	header := make([]string, len(s))
+	for i, f := range s {
+		header[i] = f.Name
+		if f.Unit != "" {
+			header[i] += "(" + f.Unit + ")"
+		}
+	}
+	return header
+}
+
+// Row 按 Schema 的顺序取出 CSV 的一行, list 和 object 字段写成 JSON.
+func (s Schema) Row(fields map[string]interface{}) []string {
+	row := make([]string, len(s))
+	for i, f := range s {
+		v, ok := fields[f.Name]
+		if !ok || v == nil {
+			continue
+		}
+		switch reflect.ValueOf(v).Kind() {
+		case reflect.Slice, reflect.Array, reflect.Struct, reflect.Map, reflect.Ptr:
+			b, err := json.Marshal(v)
+			if err != nil {
+				row[i] = fmt.Sprint(v)
+				continue
+			}
+			row[i] = string(b)
+		default:
+			row[i] = fmt.Sprint(v)
+		}
+	}
+	return row
+}
+
+// Schema 数据所属规则声明的结构, 额外产出到其他表的数据没有 Schema.
+func (d *DataCell) Schema() Schema {
+	if d.Task == nil || d.Meta.Task != d.Task.Name {
+		return nil
+	}
+	rule, ok := d.Task.Rule.Trunk[d.Meta.Rule]
+	if !ok {
+		return nil
+	}
+	return rule.ItemFields
+}
@@ -0,0 +1,132 @@
+package spider
+
+import (
+	"reflect"
+	"testing"
+)
+
+var testSchema = Schema{
+	{Name: "Index", Type: FieldInt, Required: true},
+	{Name: "NameZh", Type: FieldString, Required: true},
+	{Name: "Height", Type: FieldFloat, Unit: "m"},
+	{Name: "Types", Type: FieldList},
+	{Name: "Legendary", Type: FieldBool},
+	{Name: "Any"},
+}
+
+func TestSchemaValidate(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		fields map[string]interface{}
+		errs   []string
+	}{
+		{
+			name: "valid",
+			fields: map[string]interface{}{
+				"Index": 25, "NameZh": "皮卡丘", "Height": 0.4,
+				"Types": []string{"电"}, "Legendary": false, "Any": struct{}{},
+			},
+		},
+		{
+			name:   "optional missing",
+			fields: map[string]interface{}{"Index": int64(25), "NameZh": "皮卡丘"},
+		},
+		{
+			name:   "undeclared field ignored",
+			fields: map[string]interface{}{"Index": 25, "NameZh": "皮卡丘", "Other": 1},
+		},
+		{
+			name:   "required missing",
+			fields: map[string]interface{}{"NameZh": "皮卡丘"},
+			errs:   []string{"field Index: missing"},
+		},
+		{
+			name:   "required nil",
+			fields: map[string]interface{}{"Index": nil, "NameZh": "皮卡丘"},
+			errs:   []string{"field Index: missing"},
+		},
+		{
+			name:   "required empty",
+			fields: map[string]interface{}{"Index": 25, "NameZh": ""},
+			errs:   []string{"field NameZh: empty"},
+		},
+		{
+			name:   "wrong types",
+			fields: map[string]interface{}{"Index": "25", "NameZh": "皮卡丘", "Height": 1, "Types": "电"},
+			errs: []string{
+				"field Index: want int, got string",
+				"field Height: want float, got int",
+				"field Types: want list, got string",
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, err := range testSchema.Validate(tt.fields) {
+				got = append(got, err.Error())
+			}
+			if !reflect.DeepEqual(got, tt.errs) {
+				t.Fatalf("Validate = %q, want %q", got, tt.errs)
+			}
+		})
+	}
+}
+
+func TestSchemaHeaderRow(t *testing.T) {
+	header := testSchema.Header()
+	want := []string{"Index", "NameZh", "Height(m)", "Types", "Legendary", "Any"}
+	if !reflect.DeepEqual(header, want) {
+		t.Fatalf("Header = %q, want %q", header, want)
+	}
+
+	row := testSchema.Row(map[string]interface{}{
+		"Index": 25, "NameZh": "皮卡丘", "Height": 0.4,
+		"Types": []string{"电"}, "Any": map[string]int{"a": 1}, "Other": "x",
+	})
+	want = []string{"25", "皮卡丘", "0.4", `["电"]`, "", `{"a":1}`}
+	if !reflect.DeepEqual(row, want) {
+		t.Fatalf("Row = %q, want %q", row, want)
+	}
+}
+
+func TestDataCellFields(t *testing.T) {
+	type data struct {
+		Index  int
+		NameZh string
+		hidden string
+	}
+
+	d := &DataCell{
+		Value: &data{Index: 25, NameZh: "皮卡丘", hidden: "x"},
+		Extra: map[string]interface{}{"Gen": 1},
+	}
+	fields, err := d.Fields()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{"Index": 25, "NameZh": "皮卡丘", "Gen": 1}
+	if !reflect.DeepEqual(fields, want) {
+		t.Fatalf("Fields = %v, want %v", fields, want)
+	}
+
+	if _, err := (&DataCell{Value: 42}).Fields(); err == nil {
+		t.Fatal("want error for non struct value")
+	}
+}
+
+func TestDataCellSchema(t *testing.T) {
+	task := &Task{
+		Options: Options{Name: "pokemon"},
+		Rule:    RuleTree{Trunk: map[string]*Rule{"list": {ItemFields: testSchema}}},
+	}
+
+	d := &DataCell{Task: task, Meta: Meta{Task: "pokemon", Rule: "list"}}
+	if len(d.Schema()) != len(testSchema) {
+		t.Fatal("want the rule schema")
+	}
+	// 额外产出到其他表的数据
+	d.Meta.Task = "other"
+	if d.Schema() != nil {
+		t.Fatal("item for another table should have no schema")
+	}
+}
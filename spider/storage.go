@@ -1,19 +1,93 @@
 package spider
 
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// Storage engine 会在多个协程中同时调用 Save, 同一个任务的数据按顺序调用.
 type Storage interface {
 	Save(datas ...*DataCell) error
 	Flush() error
 }
 
+// Meta 数据的来源.
+type Meta struct {
+	Task      string // 同时也是表名
+	URL       string
+	Rule      string
+	FetchedAt time.Time
+}
+
+// DataCell 解析器输出的一条数据, Value 保持原来的类型, 由存储自己决定怎么序列化.
 type DataCell struct {
-	Task *Task
-	Data map[string]interface{}
+	Task  *Task
+	Value interface{} // 通常是结构体指针
+	Meta  Meta
+	// 处理器补充的字段, Value 里没有对应字段时放在这里
+	Extra map[string]interface{}
 }
 
 func (d *DataCell) GetTableName() string {
-	return d.Data["Task"].(string)
+	return d.Meta.Task
 }
 
 func (d *DataCell) GetTaskName() string {
-	return d.Data["Task"].(string)
+	return d.Meta.Task
+}
+
+// Fields 把 Value 按字段名展开并合并 Extra, Value 只能是结构体, 结构体指针或者 map[string]interface{}.
+func (d *DataCell) Fields() (map[string]interface{}, error) {
+	fields := make(map[string]interface{})
+
+	switch v := d.Value.(type) {
+	case map[string]interface{}:
+		for k, val := range v {
+			fields[k] = val
+		}
+	default:
+		rv := reflect.ValueOf(d.Value)
+		if rv.Kind() == reflect.Ptr && !rv.IsNil() {
+			rv = rv.Elem()
+		}
+		if rv.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("task %s: item must be a struct or map, got %T", d.Meta.Task, d.Value)
+		}
+		rt := rv.Type()
+		for i := 0; i < rv.NumField(); i++ {
+			if !rt.Field(i).IsExported() {
+				continue
+			}
+			fields[rt.Field(i).Name] = rv.Field(i).Interface()
+		}
+	}
+
+	for k, v := range d.Extra {
+		fields[k] = v
+	}
+	return fields, nil
+}
+
+// field 返回 Value 中名为 name 的可修改字段, 没有时 ok 为 false.
+// Value 是结构体值时会换成可修改的副本.
+func (d *DataCell) field(name string) (reflect.Value, bool) {
+	rv := reflect.ValueOf(d.Value)
+	switch {
+	case rv.Kind() == reflect.Ptr && !rv.IsNil() && rv.Elem().Kind() == reflect.Struct:
+		rv = rv.Elem()
+	case rv.Kind() == reflect.Struct:
+		cp := reflect.New(rv.Type())
+		cp.Elem().Set(rv)
+		d.Value = cp.Interface()
+		rv = cp.Elem()
+	default:
+		return reflect.Value{}, false
+	}
+
+	f := rv.FieldByName(name)
+	if !f.IsValid() || !f.CanSet() {
+		return reflect.Value{}, false
+	}
+	return f, true
 }
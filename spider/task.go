@@ -1,16 +1,17 @@
 package spider
 
-import "sync"
+import (
+	"context"
+)
 
 type Task struct {
-	Visited      map[string]bool
-	VisistedLock sync.Mutex
-	Rule         RuleTree
+	Rule RuleTree
 	Options
 }
 
 type Fetcher interface {
-	Get(req *Request) ([]byte, error)
+	// 只有网络错误才返回 error, 状态码交给 Validator 判断
+	Get(ctx context.Context, req *Request) (*Response, error)
 }
 
 func NewTask(opts ...Option) *Task {
@@ -1,17 +1,143 @@
 package spider
 
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var ErrMissingKey = errors.New("temp data key not found")
+
+// TempData 可以被并发读写, 保存断点时会和解析函数同时访问同一个请求的 TempData.
 type TempData struct {
+	mu   sync.RWMutex
 	data map[string]interface{}
+	// 从断点或死信文件读出来还没有解码的值, 第一次 Get 时按需要的类型解码
+	raw map[string]json.RawMessage
 }
 
+// Get 没有类型检查, 新代码应该使用 Key.
 func (t *TempData) Get(key string) interface{} {
-	return t.data[key]
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if v, ok := t.data[key]; ok {
+		return v
+	}
+	raw, ok := t.raw[key]
+	if !ok {
+		return nil
+	}
+	v, err := decodeUntyped(raw)
+	if err != nil {
+		return nil
+	}
+	t.store(key, v)
+	return v
 }
 
 func (t *TempData) Set(key string, value interface{}) error {
+	if t == nil {
+		return fmt.Errorf("set %q: nil temp data", key)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.store(key, value)
+	return nil
+}
+
+// store 调用方需持有 mu 写锁.
+func (t *TempData) store(key string, value interface{}) {
 	if t.data == nil {
 		t.data = make(map[string]interface{}, 8)
 	}
 	t.data[key] = value
+	delete(t.raw, key)
+}
+
+func (t *TempData) MarshalJSON() ([]byte, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	all := make(map[string]interface{}, len(t.data)+len(t.raw))
+	for k, v := range t.raw {
+		all[k] = v
+	}
+	for k, v := range t.data {
+		all[k] = v
+	}
+	return json.Marshal(all)
+}
+
+func (t *TempData) UnmarshalJSON(b []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.data = nil
+	t.raw = raw
 	return nil
 }
+
+// decodeUntyped 整数还原成 int, 和 Set 时的类型一致.
+func decodeUntyped(raw json.RawMessage) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	n, ok := v.(json.Number)
+	if !ok {
+		return v, nil
+	}
+	if i, err := n.Int64(); err == nil {
+		return int(i), nil
+	}
+	return n.Float64()
+}
+
+// Key 带类型的 TempData key, 例如 spider.Key[int]("index").
+// 不存在或者类型不对时 Get 返回 error, 不会悄悄变成零值.
+type Key[T any] string
+
+func (k Key[T]) Get(t *TempData) (T, error) {
+	var zero T
+	if t == nil {
+		return zero, fmt.Errorf("%w: %q", ErrMissingKey, string(k))
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if v, ok := t.data[string(k)]; ok {
+		tv, ok := v.(T)
+		if !ok {
+			return zero, fmt.Errorf("temp data key %q: want %T, got %T", string(k), zero, v)
+		}
+		return tv, nil
+	}
+
+	raw, ok := t.raw[string(k)]
+	if !ok {
+		return zero, fmt.Errorf("%w: %q", ErrMissingKey, string(k))
+	}
+	var v T
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return zero, fmt.Errorf("temp data key %q: decode %T: %w", string(k), zero, err)
+	}
+	t.store(string(k), v)
+	return v, nil
+}
+
+func (k Key[T]) Set(t *TempData, v T) error {
+	return t.Set(string(k), v)
+}
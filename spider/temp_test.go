@@ -0,0 +1,96 @@
+package spider
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestKeyRoundTrip(t *testing.T) {
+	index := Key[int]("index")
+	name := Key[string]("name")
+
+	td := &TempData{}
+	if err := index.Set(td, 25); err != nil {
+		t.Fatal(err)
+	}
+	if err := name.Set(td, "皮卡丘"); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := json.Marshal(td)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var restored TempData
+	if err := json.Unmarshal(b, &restored); err != nil {
+		t.Fatal(err)
+	}
+
+	if v, err := index.Get(&restored); err != nil || v != 25 {
+		t.Fatalf("index = %v, %v", v, err)
+	}
+	if v, err := name.Get(&restored); err != nil || v != "皮卡丘" {
+		t.Fatalf("name = %v, %v", v, err)
+	}
+	// 解码后的整数和 Set 时的类型一致
+	if v, ok := restored.Get("index").(int); !ok || v != 25 {
+		t.Fatalf("untyped index = %#v", restored.Get("index"))
+	}
+}
+
+func TestKeyErrors(t *testing.T) {
+	td := &TempData{}
+	if _, err := Key[int]("missing").Get(td); !errors.Is(err, ErrMissingKey) {
+		t.Fatalf("missing key err = %v", err)
+	}
+	if _, err := Key[int]("x").Get(nil); !errors.Is(err, ErrMissingKey) {
+		t.Fatalf("nil temp data err = %v", err)
+	}
+
+	if err := td.Set("index", "25"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Key[int]("index").Get(td); err == nil {
+		t.Fatal("want type error")
+	}
+
+	var restored TempData
+	if err := json.Unmarshal([]byte(`{"index":"25"}`), &restored); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Key[int]("index").Get(&restored); err == nil {
+		t.Fatal("want decode error")
+	}
+}
+
+// 保存断点时 MarshalJSON 和解析函数的 Get 同时进行, 用 -race 运行.
+func TestTempDataConcurrent(t *testing.T) {
+	var td TempData
+	if err := json.Unmarshal([]byte(`{"a":1,"b":"x","c":2}`), &td); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if _, err := json.Marshal(&td); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				Key[int]("a").Get(&td)
+				Key[string]("b").Get(&td)
+				td.Get("c")
+			}
+		}()
+	}
+	wg.Wait()
+}
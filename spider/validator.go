@@ -0,0 +1,84 @@
+package spider
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	FetchedAt  time.Time // 为零值时 engine 使用收到响应的时间
+}
+
+type Verdict int
+
+const (
+	Accept Verdict = iota
+	Retry          // 在 MaxAttempts 内重新抓取, 不受 RetryableStatus 限制
+	Reject         // 永久失败, 不再重试
+)
+
+// Validator 在解析前检查响应, 非 Accept 时返回的 error 说明原因.
+type Validator func(resp *Response) (Verdict, error)
+
+var DefaultValidator Validator = StatusValidator
+
+// StatusValidator 2xx 通过, 408, 429 和 5xx 重试, 其余状态码永久失败.
+func StatusValidator(resp *Response) (Verdict, error) {
+	code := resp.StatusCode
+	switch {
+	case code >= 200 && code < 300:
+		return Accept, nil
+	case code == http.StatusRequestTimeout, code == http.StatusTooManyRequests, code >= 500:
+		return Retry, &StatusError{Code: code}
+	default:
+		return Reject, &StatusError{Code: code}
+	}
+}
+
+// MinLength body 太短时重试, 通常是页面没有加载完整.
+func MinLength(n int) Validator {
+	return func(resp *Response) (Verdict, error) {
+		if len(resp.Body) < n {
+			return Retry, fmt.Errorf("not correct length:%d", len(resp.Body))
+		}
+		return Accept, nil
+	}
+}
+
+// RejectContent body 包含任意 markers 时永久失败, 例如页面不存在的提示.
+func RejectContent(markers ...string) Validator {
+	return matchContent(Reject, markers)
+}
+
+// RetryContent body 包含任意 markers 时重试, 例如维护中的提示.
+func RetryContent(markers ...string) Validator {
+	return matchContent(Retry, markers)
+}
+
+func matchContent(verdict Verdict, markers []string) Validator {
+	return func(resp *Response) (Verdict, error) {
+		for _, m := range markers {
+			if bytes.Contains(resp.Body, []byte(m)) {
+				return verdict, fmt.Errorf("body contains %q", m)
+			}
+		}
+		return Accept, nil
+	}
+}
+
+// Validators 依次检查, 返回第一个不是 Accept 的结果.
+func Validators(validators ...Validator) Validator {
+	return func(resp *Response) (Verdict, error) {
+		for _, v := range validators {
+			if verdict, err := v(resp); verdict != Accept {
+				return verdict, err
+			}
+		}
+		return Accept, nil
+	}
+}
@@ -0,0 +1,40 @@
+package spider
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidators(t *testing.T) {
+	v := Validators(
+		StatusValidator,
+		RejectContent("此页面目前没有内容"),
+		RetryContent("数据库已被锁定"),
+		MinLength(10),
+	)
+
+	for _, tt := range []struct {
+		name string
+		code int
+		body string
+		want Verdict
+	}{
+		{"ok", 200, strings.Repeat("x", 10), Accept},
+		{"not found", 404, strings.Repeat("x", 10), Reject},
+		{"too many requests", 429, "", Retry},
+		{"server error", 503, "", Retry},
+		{"missing page", 200, "此页面目前没有内容" + strings.Repeat("x", 10), Reject},
+		{"maintenance", 200, "数据库已被锁定" + strings.Repeat("x", 10), Retry},
+		{"short", 200, "x", Retry},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := v(&Response{StatusCode: tt.code, Body: []byte(tt.body)})
+			if got != tt.want {
+				t.Fatalf("verdict = %v, want %v (err %v)", got, tt.want, err)
+			}
+			if (got == Accept) != (err == nil) {
+				t.Fatalf("verdict %v with err %v", got, err)
+			}
+		})
+	}
+}
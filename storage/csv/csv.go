@@ -0,0 +1,124 @@
+package csv
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/Ysoding/pokemon-wiki-spider/spider"
+	"go.uber.org/zap"
+)
+
+// CSVStorage 每个表一个 CSV 文件, 表头由规则的 ItemFields 生成.
+// 规则必须声明 ItemFields, 否则后面的数据多出的字段没有对应的列.
+type CSVStorage struct {
+	mu     sync.Mutex
+	dir    string
+	tables map[string]*table
+	options
+}
+
+type table struct {
+	f      *os.File
+	w      *csv.Writer
+	schema spider.Schema
+}
+
+func New(dir string, opts ...Option) (*CSVStorage, error) {
+	options := defaultOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &CSVStorage{dir: dir, tables: make(map[string]*table), options: options}, nil
+}
+
+// Save 没有声明 ItemFields 或者无法展开的数据只丢弃这一条, 和 mongo 一致.
+func (s *CSVStorage) Save(datas ...*spider.DataCell) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, d := range datas {
+		name := d.GetTableName()
+		schema := d.Schema()
+		if len(schema) == 0 {
+			s.logger.Error("csv storage skip item without ItemFields", zap.String("table", name))
+			continue
+		}
+		fields, err := d.Fields()
+		if err != nil {
+			s.logger.Error("csv storage skip item", zap.String("table", name), zap.Error(err))
+			continue
+		}
+
+		t, err := s.table(name, schema)
+		if err != nil {
+			return err
+		}
+		if err := t.w.Write(t.schema.Row(fields)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// table 第一次写入时打开文件, 文件为空时写表头.
+func (s *CSVStorage) table(name string, schema spider.Schema) (*table, error) {
+	if t, ok := s.tables[name]; ok {
+		return t, nil
+	}
+
+	f, err := os.OpenFile(filepath.Join(s.dir, name+".csv"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	t := &table{f: f, w: csv.NewWriter(f), schema: schema}
+	if info.Size() == 0 {
+		if err := t.w.Write(schema.Header()); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("write header of %s: %w", name, err)
+		}
+	}
+	s.tables[name] = t
+	return t, nil
+}
+
+func (s *CSVStorage) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, t := range s.tables {
+		t.w.Flush()
+		if err := t.w.Error(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *CSVStorage) Close() error {
+	if err := s.Flush(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for name, t := range s.tables {
+		if err := t.f.Close(); err != nil {
+			return err
+		}
+		delete(s.tables, name)
+	}
+	return nil
+}
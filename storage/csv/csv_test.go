@@ -0,0 +1,93 @@
+package csv
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Ysoding/pokemon-wiki-spider/spider"
+)
+
+func newTask(name string, schema spider.Schema) *spider.Task {
+	return &spider.Task{
+		Options: spider.Options{Name: name},
+		Rule: spider.RuleTree{
+			Trunk: map[string]*spider.Rule{"list": {ItemFields: schema}},
+		},
+	}
+}
+
+func cell(task *spider.Task, value interface{}) *spider.DataCell {
+	return &spider.DataCell{
+		Task:  task,
+		Value: value,
+		Meta:  spider.Meta{Task: task.Name, Rule: "list"},
+	}
+}
+
+func TestSave(t *testing.T) {
+	dir := t.TempDir()
+	s, err := New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	task := newTask("nature", spider.Schema{
+		{Name: "NameZh", Type: spider.FieldString},
+		{Name: "Index", Type: spider.FieldInt},
+	})
+	noSchema := newTask("raw", nil)
+
+	err = s.Save(
+		cell(task, map[string]interface{}{"NameZh": "勤奋", "Index": 1}),
+		// 无法展开的数据只丢弃这一条
+		cell(task, 42),
+		cell(noSchema, map[string]interface{}{"a": 1}),
+		cell(task, map[string]interface{}{"NameZh": "怕寂寞", "Extra": "x"}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, "nature.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "NameZh,Index\n勤奋,1\n怕寂寞,\n"
+	if string(b) != want {
+		t.Fatalf("nature.csv = %q, want %q", b, want)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "raw.csv")); !os.IsNotExist(err) {
+		t.Fatalf("item without schema written, stat err = %v", err)
+	}
+}
+
+func TestAppendKeepsHeader(t *testing.T) {
+	dir := t.TempDir()
+	task := newTask("nature", spider.Schema{{Name: "NameZh"}})
+
+	for _, name := range []string{"勤奋", "怕寂寞"} {
+		s, err := New(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Save(cell(task, map[string]interface{}{"NameZh": name})); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, "nature.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "NameZh\n勤奋\n怕寂寞\n"; string(b) != want {
+		t.Fatalf("nature.csv = %q, want %q", b, want)
+	}
+}
@@ -0,0 +1,19 @@
+package csv
+
+import "go.uber.org/zap"
+
+type Option func(opts *options)
+
+type options struct {
+	logger *zap.Logger
+}
+
+var defaultOptions = options{
+	logger: zap.NewNop(),
+}
+
+func WithLogger(logger *zap.Logger) Option {
+	return func(opts *options) {
+		opts.logger = logger
+	}
+}
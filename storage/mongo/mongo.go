@@ -1,50 +1,95 @@
 package mongo
 
 import (
+	"sync"
+
 	"github.com/Ysoding/pokemon-wiki-spider/db/mongodb"
 	"github.com/Ysoding/pokemon-wiki-spider/spider"
+	"go.uber.org/zap"
 )
 
 type MongoStorage struct {
+	mu         sync.Mutex
 	dataDocker []*spider.DataCell // cache
-	db         mongodb.DBer
+	// 写入数据库时持有读锁, Flush 用写锁等待正在进行的写入
+	inserting sync.RWMutex
+	db        mongodb.DBer
 	options
 }
 
+// Save 缓存满了时取走缓存, 解锁后再写入数据库, 写入期间其他协程可以继续 Save.
 func (m *MongoStorage) Save(datas ...*spider.DataCell) error {
+	var batches [][]*spider.DataCell
+	m.mu.Lock()
 	for _, data := range datas {
 		if len(m.dataDocker) >= m.batchCount {
-			if err := m.Flush(); err != nil {
-				return err
-			}
+			batches = append(batches, m.dataDocker)
+			m.dataDocker = nil
 		}
-
 		m.dataDocker = append(m.dataDocker, data)
 	}
+	if len(batches) == 0 {
+		m.mu.Unlock()
+		return nil
+	}
+	m.inserting.RLock()
+	m.mu.Unlock()
+	defer m.inserting.RUnlock()
+
+	for _, batch := range batches {
+		if err := m.insert(batch); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// Flush 写入缓存中的数据, 并等待其他 Save 正在进行的写入完成.
 func (m *MongoStorage) Flush() error {
-	if len(m.dataDocker) == 0 {
+	m.mu.Lock()
+	batch := m.dataDocker
+	m.dataDocker = nil
+	m.mu.Unlock()
+
+	err := m.insert(batch)
+	m.inserting.Lock()
+	m.inserting.Unlock()
+	return err
+}
+
+// insert 缓存里可能混有多个任务的数据, 按表分别写入.
+func (m *MongoStorage) insert(batch []*spider.DataCell) error {
+	if len(batch) == 0 {
 		return nil
 	}
 	m.logger.Info("mongo storage start flush data")
 
-	defer func() {
-		m.dataDocker = nil
-	}()
+	var tables []string
+	data := make(map[string][]interface{})
 
-	data := make([]interface{}, 0)
-
-	for _, d := range m.dataDocker {
-		nd := d.Data["Data"].(map[string]interface{})
-		data = append(data, nd)
+	for _, d := range batch {
+		table := d.GetTableName()
+		// 坏数据只丢弃这一条
+		doc, err := m.codec.Encode(d)
+		if err != nil {
+			m.logger.Error("mongo storage encode item failed", zap.String("table", table), zap.Error(err))
+			continue
+		}
+		if _, ok := data[table]; !ok {
+			tables = append(tables, table)
+		}
+		data[table] = append(data[table], doc)
 	}
 
-	return m.db.InsertMany(mongodb.TableData{
-		TableName: m.dataDocker[0].GetTableName(),
-		Data:      data,
-	})
+	for _, table := range tables {
+		if err := m.db.InsertMany(mongodb.TableData{
+			TableName: table,
+			Data:      data[table],
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func New(opts ...Option) (*MongoStorage, error) {
@@ -0,0 +1,88 @@
+package mongo
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Ysoding/pokemon-wiki-spider/db/mongodb"
+	"github.com/Ysoding/pokemon-wiki-spider/spider"
+)
+
+// blockDB 第一次 InsertMany 阻塞到 release 关闭.
+type blockDB struct {
+	mu       sync.Mutex
+	calls    int
+	inserted int
+	started  chan struct{}
+	release  chan struct{}
+}
+
+func (db *blockDB) Insert(table mongodb.TableData) error { return nil }
+
+func (db *blockDB) InsertMany(table mongodb.TableData) error {
+	db.mu.Lock()
+	db.calls++
+	first := db.calls == 1
+	db.mu.Unlock()
+
+	if first {
+		close(db.started)
+		<-db.release
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.inserted += len(table.Data)
+	return nil
+}
+
+func cell(name string) *spider.DataCell {
+	return &spider.DataCell{
+		Value: map[string]interface{}{"Name": name},
+		Meta:  spider.Meta{Task: "pokemon"},
+	}
+}
+
+func TestSaveDoesNotBlockDuringInsert(t *testing.T) {
+	db := &blockDB{started: make(chan struct{}), release: make(chan struct{})}
+	options := defaultOptions
+	options.batchCount = 2
+	s := &MongoStorage{db: db, options: options}
+
+	saved := make(chan error, 1)
+	go func() { saved <- s.Save(cell("a"), cell("b"), cell("c")) }()
+	<-db.started
+
+	// 第一批还在写入, 其他协程的 Save 不用等
+	done := make(chan error, 1)
+	go func() { done <- s.Save(cell("d")) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Save blocked by an insert in progress")
+	}
+
+	// Flush 要等正在进行的写入完成
+	flushed := make(chan error, 1)
+	go func() { flushed <- s.Flush() }()
+	select {
+	case <-flushed:
+		t.Fatal("Flush returned before the insert in progress finished")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(db.release)
+	if err := <-flushed; err != nil {
+		t.Fatal(err)
+	}
+	if err := <-saved; err != nil {
+		t.Fatal(err)
+	}
+	if db.inserted != 4 {
+		t.Fatalf("inserted %d items, want 4", db.inserted)
+	}
+}
@@ -2,6 +2,7 @@ package mongo
 
 import (
 	"github.com/Ysoding/pokemon-wiki-spider/global"
+	"github.com/Ysoding/pokemon-wiki-spider/storage"
 	"go.uber.org/zap"
 )
 
@@ -12,12 +13,14 @@ type options struct {
 	uri        string
 	batchCount int
 	dbName     string
+	codec      storage.Codec
 }
 
 var defaultOptions = options{
 	logger:     zap.NewNop(),
 	batchCount: global.DefaultBatchCount,
 	dbName:     global.DefaultMongoDatabaseName,
+	codec:      storage.FieldsCodec,
 }
 
 func WithConnURI(url string) Option {
@@ -41,3 +44,10 @@ func WithDatabaseName(dbName string) Option {
 		opts.dbName = dbName
 	}
 }
+
+// WithCodec 默认使用 storage.FieldsCodec, 每个字段一列.
+func WithCodec(codec storage.Codec) Option {
+	return func(opts *options) {
+		opts.codec = codec
+	}
+}
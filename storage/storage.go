@@ -1 +1,34 @@
 package storage
+
+import "github.com/Ysoding/pokemon-wiki-spider/spider"
+
+// Codec 把 DataCell 转成具体存储需要的格式.
+type Codec interface {
+	Encode(d *spider.DataCell) (interface{}, error)
+}
+
+type CodecFunc func(d *spider.DataCell) (interface{}, error)
+
+func (f CodecFunc) Encode(d *spider.DataCell) (interface{}, error) {
+	return f(d)
+}
+
+// FieldsCodec 按字段名展开成 map, 每个字段一列, 不包含 Meta.
+var FieldsCodec = CodecFunc(func(d *spider.DataCell) (interface{}, error) {
+	return d.Fields()
+})
+
+// MetaCodec 在字段之外加上来源信息, 字段放在 Data 下.
+var MetaCodec = CodecFunc(func(d *spider.DataCell) (interface{}, error) {
+	fields, err := d.Fields()
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"Task":      d.Meta.Task,
+		"URL":       d.Meta.URL,
+		"Rule":      d.Meta.Rule,
+		"FetchedAt": d.Meta.FetchedAt,
+		"Data":      fields,
+	}, nil
+})